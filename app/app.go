@@ -0,0 +1,244 @@
+// Package app bootstraps the commons dependencies of a service. An App loads
+// the logger and the declared dependencies with consistent prefixes, initializes
+// them in order and tears them down in reverse order when the service stops.
+//
+//	a := app.New("my-service", "1.0.0", app.WithConfigFile("app.conf"), app.WithTracing(), app.WithRedis())
+//	if err := a.Init(ctx); err != nil {
+//		log.Fatal(err)
+//	}
+//	err := a.Run(ctx, func(ctx context.Context) error {
+//		return ce.StartReceiver(ctx, handler)
+//	})
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/onmi-bv/commons/confighelper"
+	"github.com/onmi-bv/commons/dgraph"
+	"github.com/onmi-bv/commons/graphql"
+	"github.com/onmi-bv/commons/health"
+	"github.com/onmi-bv/commons/logger"
+	"github.com/onmi-bv/commons/mongo"
+	"github.com/onmi-bv/commons/redis"
+	"github.com/onmi-bv/commons/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// Prefixes of the environment variables per dependency.
+const (
+	LoggerPrefix  = "LOG"
+	TracingPrefix = "TRACING"
+	RedisPrefix   = "REDIS"
+	MongoPrefix   = "MONGO"
+	DgraphPrefix  = "DGRAPH"
+	GraphQLPrefix = "GRAPHQL"
+)
+
+// Default timeouts.
+const (
+	DefaultInitTimeout     = 30 * time.Second
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// dependency is initialized by Init and closed by Shutdown.
+type dependency struct {
+	name  string
+	init  func(ctx context.Context) error
+	close func(ctx context.Context) error // optional
+}
+
+// App holds the dependencies of a service.
+type App struct {
+	name            string
+	version         string
+	path            string
+	secrets         confighelper.Resolver
+	initTimeout     time.Duration
+	shutdownTimeout time.Duration
+
+	deps    []dependency
+	started int // number of initialized dependencies
+
+	logger        logger.Logger
+	tracer        tracing.Tracer
+	traceProvider tracing.TraceProvider
+	redis         *redis.Client
+	mongo         *mongo.Client
+	dgraph        *dgraph.Client
+	graphql       *graphql.Client
+}
+
+// New creates an app with the given name and version. The logger is always
+// a dependency and configures the logrus standard logger; the others are declared
+// with the options.
+func New(name string, version string, opts ...Option) *App {
+	a := &App{
+		name:            name,
+		version:         version,
+		initTimeout:     DefaultInitTimeout,
+		shutdownTimeout: DefaultShutdownTimeout,
+	}
+	a.deps = append(a.deps, dependency{
+		name: "logger",
+		init: a.initLogger,
+		close: func(ctx context.Context) error {
+			return a.logger.Close(ctx)
+		},
+	})
+
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Init initializes the dependencies in the order they were declared, after the logger.
+// Each dependency is given the init timeout. If one fails, the dependencies
+// initialized before it are shut down.
+func (a *App) Init(ctx context.Context) error {
+	for _, d := range a.deps[a.started:] {
+		start := time.Now()
+
+		dctx, cancel := context.WithTimeout(ctx, a.initTimeout)
+		err := d.init(dctx)
+		cancel()
+
+		if err != nil {
+			err = fmt.Errorf("cannot init %s: %v", d.name, err)
+			sctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+			defer cancel()
+			if serr := a.Shutdown(sctx); serr != nil {
+				a.log().Error(serr)
+			}
+			return err
+		}
+
+		a.started++
+		a.log().Debugf("initialized %s in %v", d.name, time.Since(start))
+	}
+	return nil
+}
+
+// Run calls fn with a context that is canceled on SIGTERM or SIGINT.
+// When fn returns, the dependencies are shut down within the shutdown timeout.
+// Run returns the error of fn, or else of the shutdown.
+func (a *App) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	err := fn(ctx)
+	if ctx.Err() != nil {
+		a.log().Infof("shutting down %s", a.name)
+	}
+
+	sctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+
+	if serr := a.Shutdown(sctx); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// Shutdown closes the initialized dependencies in reverse order.
+// All dependencies are closed, even if some of them fail.
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []string
+
+	for ; a.started > 0; a.started-- {
+		d := a.deps[a.started-1]
+		if d.close == nil {
+			continue
+		}
+		if err := d.close(ctx); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", d.name, err))
+			continue
+		}
+		a.log().Debugf("closed %s", d.name)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot shut down: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Logger returns the logger, or nil before Init.
+func (a *App) Logger() *logrus.Logger {
+	return a.logger.Logger
+}
+
+// Tracer returns the tracer, or nil if tracing is not declared or failed to initialize.
+func (a *App) Tracer() tracing.Tracer {
+	return a.tracer
+}
+
+// TraceProvider returns the trace provider, or nil if tracing is not declared or failed to initialize.
+func (a *App) TraceProvider() tracing.TraceProvider {
+	return a.traceProvider
+}
+
+// Redis returns the redis client, or nil if redis is not declared or failed to initialize.
+func (a *App) Redis() *redis.Client {
+	return a.redis
+}
+
+// Mongo returns the mongo client, or nil if mongo is not declared or failed to initialize.
+func (a *App) Mongo() *mongo.Client {
+	return a.mongo
+}
+
+// Dgraph returns the dgraph client, or nil if dgraph is not declared or failed to initialize.
+func (a *App) Dgraph() *dgraph.Client {
+	return a.dgraph
+}
+
+// GraphQL returns the graphql client, or nil if graphql is not declared or failed to initialize.
+func (a *App) GraphQL() *graphql.Client {
+	return a.graphql
+}
+
+// HealthHandler returns a handler serving /healthz and /readyz, with a readiness
+// check for every declared data client. Call it after Init.
+func (a *App) HealthHandler(opts ...health.Option) *health.Handler {
+	h := health.NewHandler(opts...)
+	if a.redis != nil {
+		h.AddReadinessCheck("redis", a.redis)
+	}
+	if a.mongo != nil {
+		h.AddReadinessCheck("mongo", a.mongo)
+	}
+	if a.dgraph != nil {
+		h.AddReadinessCheck("dgraph", a.dgraph)
+	}
+	if a.graphql != nil {
+		h.AddReadinessCheck("graphql", a.graphql)
+	}
+	return h
+}
+
+// log returns the logger, falling back to the standard logger before Init.
+func (a *App) log() *logrus.Logger {
+	if a.logger.Logger != nil {
+		return a.logger.Logger
+	}
+	return logrus.StandardLogger()
+}
+
+func (a *App) initLogger(ctx context.Context) (err error) {
+	a.logger, err = logger.Init(ctx, logger.Configuration{
+		AppName:  a.name,
+		Version:  a.version,
+		Path:     a.path,
+		Prefix:   LoggerPrefix,
+		Standard: true,
+	})
+	return err
+}
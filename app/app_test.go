@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// fake declares a dependency that records its calls in calls.
+func fake(name string, calls *[]string, initErr error) Option {
+	return func(a *App) {
+		a.deps = append(a.deps, dependency{
+			name: name,
+			init: func(ctx context.Context) error {
+				*calls = append(*calls, "init "+name)
+				return initErr
+			},
+			close: func(ctx context.Context) error {
+				*calls = append(*calls, "close "+name)
+				return nil
+			},
+		})
+	}
+}
+
+func TestAppOrder(t *testing.T) {
+	var calls []string
+	a := New("test", "0.0.1", fake("a", &calls, nil), fake("b", &calls, nil))
+
+	if err := a.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if a.Logger() == nil {
+		t.Error("Logger() = nil after Init")
+	}
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	want := []string{"init a", "init b", "close b", "close a"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestAppInitFailure(t *testing.T) {
+	var calls []string
+	a := New("test", "0.0.1", fake("a", &calls, nil), fake("b", &calls, errors.New("boom")), fake("c", &calls, nil))
+
+	err := a.Init(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "cannot init b") {
+		t.Fatalf("Init() error = %v, want failure of b", err)
+	}
+
+	want := []string{"init a", "init b", "close a"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestAppInitTimeout(t *testing.T) {
+	slow := func(a *App) {
+		a.deps = append(a.deps, dependency{
+			name: "slow",
+			init: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+	}
+	a := New("test", "0.0.1", WithInitTimeout(10*time.Millisecond), slow)
+
+	err := a.Init(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("Init() error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestAppRun(t *testing.T) {
+	var calls []string
+	a := New("test", "0.0.1", fake("a", &calls, nil))
+	if err := a.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := a.Run(ctx, func(ctx context.Context) error {
+		cancel()
+		<-ctx.Done()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []string{"init a", "close a"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestAppDgraphClose(t *testing.T) {
+	t.Setenv(DgraphPrefix+"_GRPC_HOST", "localhost:9080")
+	t.Setenv(DgraphPrefix+"_HEALTH_URL", "http://localhost:8080/health")
+	a := New("test", "0.0.1", WithDgraph())
+
+	if err := a.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if err := a.Dgraph().Close(); err == nil {
+		t.Error("Close() error = nil after Shutdown, want the connection closed")
+	}
+}
+
+func TestAppDgraphInitFailure(t *testing.T) {
+	t.Setenv(DgraphPrefix+"_GRPC_HOST", "")
+	a := New("test", "0.0.1", WithDgraph())
+
+	if err := a.Init(context.Background()); err == nil {
+		t.Fatal("Init() error = nil, want the missing dgraph host")
+	}
+	if a.Dgraph() != nil {
+		t.Error("Dgraph() != nil after a failed init")
+	}
+}
+
+func TestAppTracingVersion(t *testing.T) {
+	t.Setenv(TracingPrefix+"_EXPORTER", "stdout")
+	a := New("test", "0.0.1", WithTracing())
+
+	if err := a.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer a.Shutdown(context.Background())
+
+	_, span := a.Tracer().Start(context.Background(), "version")
+	defer span.End()
+	ro, ok := span.(sdktrace.ReadOnlySpan)
+	if !ok {
+		t.Fatalf("span %T is not recorded", span)
+	}
+	if v, _ := ro.Resource().Set().Value(attribute.Key("service.version")); v.AsString() != "0.0.1" {
+		t.Errorf("service.version = %q, want the app version", v.AsString())
+	}
+}
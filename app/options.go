@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/onmi-bv/commons/confighelper"
+	"github.com/onmi-bv/commons/dgraph"
+	"github.com/onmi-bv/commons/graphql"
+	"github.com/onmi-bv/commons/mongo"
+	"github.com/onmi-bv/commons/redis"
+	"github.com/onmi-bv/commons/tracing"
+)
+
+// Option configures an App.
+type Option func(*App)
+
+// WithConfigFile sets the config file of all dependencies.
+func WithConfigFile(path string) Option {
+	return func(a *App) {
+		a.path = path
+	}
+}
+
+// WithSecrets sets the resolver for secret:// references of the dependencies that support them.
+func WithSecrets(r confighelper.Resolver) Option {
+	return func(a *App) {
+		a.secrets = r
+	}
+}
+
+// WithInitTimeout sets the timeout for initializing each dependency.
+func WithInitTimeout(d time.Duration) Option {
+	return func(a *App) {
+		a.initTimeout = d
+	}
+}
+
+// WithShutdownTimeout sets the timeout for shutting down all dependencies.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(a *App) {
+		a.shutdownTimeout = d
+	}
+}
+
+// WithTracing declares tracing, with the version of the app as service.version.
+// The options are applied after the config file and env. On shutdown, the pending spans are flushed and the exporter is closed.
+func WithTracing(opts ...tracing.TraceOption) Option {
+	return func(a *App) {
+		var shutdown tracing.ShutdownFunc
+		a.deps = append(a.deps, dependency{
+			name: "tracing",
+			init: func(ctx context.Context) (err error) {
+				a.tracer, a.traceProvider, shutdown, err = tracing.Initialize(ctx, tracing.Configuration{
+					Name:    a.name,
+					Path:    a.path,
+					Prefix:  TracingPrefix,
+					Secrets: a.secrets,
+					Options: append([]tracing.TraceOption{tracing.WithServiceVersion(a.version)}, opts...),
+				})
+				return err
+			},
+			close: func(ctx context.Context) error {
+				if shutdown == nil {
+					return nil
+				}
+				return shutdown(ctx)
+			},
+		})
+	}
+}
+
+// WithRedis declares a redis client.
+func WithRedis() Option {
+	return func(a *App) {
+		a.deps = append(a.deps, dependency{
+			name: "redis",
+			init: func(ctx context.Context) error {
+				c, err := redis.Init(ctx, redis.Configuration{
+					Path:    a.path,
+					Prefix:  RedisPrefix,
+					Secrets: a.secrets,
+				})
+				if err != nil {
+					if c.UniversalClient != nil {
+						c.Close()
+					}
+					return err
+				}
+				a.redis = &c
+				return nil
+			},
+			close: func(ctx context.Context) error {
+				return a.redis.Close()
+			},
+		})
+	}
+}
+
+// WithMongo declares a mongo client.
+func WithMongo() Option {
+	return func(a *App) {
+		a.deps = append(a.deps, dependency{
+			name: "mongo",
+			init: func(ctx context.Context) error {
+				c, err := mongo.Init(ctx, mongo.Configuration{
+					AppName: a.name,
+					Path:    a.path,
+					Prefix:  MongoPrefix,
+					Secrets: a.secrets,
+				})
+				if err != nil {
+					if c.Client != nil {
+						c.Disconnect(ctx)
+					}
+					return err
+				}
+				a.mongo = &c
+				return nil
+			},
+			close: func(ctx context.Context) error {
+				return a.mongo.Disconnect(ctx)
+			},
+		})
+	}
+}
+
+// WithDgraph declares a dgraph client.
+func WithDgraph() Option {
+	return func(a *App) {
+		a.deps = append(a.deps, dependency{
+			name: "dgraph",
+			init: func(ctx context.Context) error {
+				c, err := dgraph.Init(ctx, dgraph.Configuration{
+					Path:    a.path,
+					Prefix:  DgraphPrefix,
+					Secrets: a.secrets,
+				})
+				if err != nil {
+					c.Close()
+					return err
+				}
+				a.dgraph = &c
+				return nil
+			},
+			close: func(ctx context.Context) error {
+				return a.dgraph.Close()
+			},
+		})
+	}
+}
+
+// WithGraphQL declares a graphql client.
+func WithGraphQL(opts ...graphql.ClientOption) Option {
+	return func(a *App) {
+		a.deps = append(a.deps, dependency{
+			name: "graphql",
+			init: func(ctx context.Context) error {
+				c, err := graphql.Init(ctx, graphql.Configuration{
+					Path:    a.path,
+					Prefix:  GraphQLPrefix,
+					Secrets: a.secrets,
+				}, opts...)
+				if err != nil {
+					return err
+				}
+				a.graphql = &c
+				return nil
+			},
+		})
+	}
+}
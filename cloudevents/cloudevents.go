@@ -2,11 +2,12 @@ package cloudevents
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
 
 	"cloud.google.com/go/pubsub"
 	cepubsub "github.com/cloudevents/sdk-go/protocol/pubsub/v2"
@@ -16,9 +17,9 @@ import (
 	"github.com/cloudevents/sdk-go/v2/event"
 	"github.com/cloudevents/sdk-go/v2/protocol"
 	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/onmi-bv/commons/internal/cetrace"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"go.opentelemetry.io/otel/trace"
 )
 
 // Client defines the cloudevent client
@@ -26,6 +27,9 @@ type Client struct {
 	Protocol Protocol
 	cloudevents.Client
 	receiverPort int
+	healthcheck  func(ctx context.Context) error
+	middleware   []func(http.Handler) http.Handler
+	close        func() error
 }
 
 // Protocol for cloud event
@@ -37,7 +41,10 @@ const (
 	PubSubProtocol Protocol = "pubsub"
 )
 
-// StartReceiver starts an http receiver able to parse different protocols
+// StartReceiver starts an http receiver able to parse different protocols.
+// fn is called with the context of the request, derived from ctx so that it carries the
+// values of ctx, and carrying the span context set by the middleware, or else the one of
+// the traceparent extension of the event.
 func (c *Client) StartReceiver(ctx context.Context, fn interface{}) error {
 
 	switch fn.(type) {
@@ -55,7 +62,7 @@ func (c *Client) StartReceiver(ctx context.Context, fn interface{}) error {
 					return
 				}
 
-				ctx, ev, err := NewEventFromHTTPRequest(ctx, r, c.Protocol)
+				ctx, ev, err := NewEventFromHTTPRequest(r.Context(), r, c.Protocol)
 				if err != nil {
 					log.Errorf("cannot convert request to a valid cloudevent: %v", err)
 					http.Error(w, fmt.Sprintf("cannot convert request to a valid cloudevent: %v", err), http.StatusBadRequest)
@@ -94,10 +101,19 @@ func (c *Client) StartReceiver(ctx context.Context, fn interface{}) error {
 			},
 		))
 
+		var handler http.Handler = mux
+		for i := len(c.middleware) - 1; i >= 0; i-- {
+			handler = c.middleware[i](handler)
+		}
+
 		// Create a server listening on port 8000
 		srv := &http.Server{
 			Addr:    fmt.Sprintf(":%d", c.receiverPort),
-			Handler: mux,
+			Handler: handler,
+			// requests keep the values of ctx, i.e. a logger set with IntoContext
+			BaseContext: func(net.Listener) context.Context {
+				return ctx
+			},
 		}
 
 		go func() {
@@ -116,12 +132,32 @@ func (c *Client) StartReceiver(ctx context.Context, fn interface{}) error {
 	}
 }
 
+// Send sends event with the span context of ctx as the traceparent and tracestate extensions.
+func (c *Client) Send(ctx context.Context, e event.Event) protocol.Result {
+	cetrace.Propagator{}.Inject(ctx, &e)
+	return c.Client.Send(ctx, e)
+}
+
+// Request sends event with the span context of ctx as the traceparent and tracestate
+// extensions, and waits for the response event.
+func (c *Client) Request(ctx context.Context, e event.Event) (*event.Event, protocol.Result) {
+	cetrace.Propagator{}.Inject(ctx, &e)
+	return c.Client.Request(ctx, e)
+}
+
 // WithPort sets the receiver port for StartReceiver func.
 func (c *Client) WithPort(port int) *Client {
 	c.receiverPort = port
 	return c
 }
 
+// WithMiddleware wraps the receiver mux of StartReceiver with m, i.e. logger.HTTPMiddleware.
+// The first middleware added is the outermost.
+func (c *Client) WithMiddleware(m func(http.Handler) http.Handler) *Client {
+	c.middleware = append(c.middleware, m)
+	return c
+}
+
 // CloudEvents creates and initilizes cloudevent with http protocol.
 func CloudEvents(ctx context.Context, port int) (ce cloudevents.Client, err error) {
 
@@ -152,28 +188,116 @@ func HTTP(ctx context.Context, port int, opts ...cloudeventsclient.Option) (c Cl
 		return c, fmt.Errorf("failed to create cloudevent client, %v", err)
 	}
 
-	return Client{HTTPProtocol, ce, port}, nil
+	return Client{
+		Protocol:     HTTPProtocol,
+		Client:       ce,
+		receiverPort: port,
+		healthcheck:  httpHealthcheck(protocol),
+	}, nil
 }
 
 // PubSub creates and initilizes cloudevent with pubsub protocol.
+// Without options, the project and topic are read from GOOGLE_CLOUD_PROJECT and PUBSUB_TOPIC,
+// and Healthcheck checks that the topic exists; Close closes the pubsub client. With options,
+// the protocol hides its pubsub client and Healthcheck cannot probe it.
 func PubSub(ctx context.Context, opts ...cepubsub.Option) (c Client, err error) {
 
+	var healthcheck func(ctx context.Context) error
+	var closer func() error
+
 	if len(opts) == 0 {
+		client, err := pubsub.NewClient(ctx, os.Getenv(cepubsub.DefaultProjectEnvKey))
+		if err != nil {
+			return c, fmt.Errorf("failed to create pubsub client, %v", err)
+		}
+		topic := client.Topic(os.Getenv(cepubsub.DefaultTopicEnvKey))
+		healthcheck = topicHealthcheck(topic)
+		closer = client.Close
+
+		opts = append(opts, cepubsub.WithClient(client))
 		opts = append(opts, cepubsub.WithTopicIDFromDefaultEnv())
 		opts = append(opts, cepubsub.WithProjectIDFromDefaultEnv())
 	}
 
 	protocol, err := cepubsub.New(ctx, opts...)
 	if err != nil {
+		if closer != nil {
+			closer()
+		}
 		return c, fmt.Errorf("failed to create cloudevent pubsub protocol, %v", err)
 	}
 
 	ce, err := cloudevents.NewClientObserved(protocol, cloudevents.WithTimeNow(), cloudevents.WithUUIDs())
 	if err != nil {
+		if closer != nil {
+			closer()
+		}
 		return c, fmt.Errorf("failed to create cloudevent client, %v", err)
 	}
 
-	return Client{PubSubProtocol, ce, 0}, nil
+	return Client{
+		Protocol:    PubSubProtocol,
+		Client:      ce,
+		healthcheck: healthcheck,
+		close:       closer,
+	}, nil
+}
+
+// Close releases the pubsub client created by PubSub without options.
+// For other clients, there is nothing to close.
+func (c *Client) Close() error {
+	if c.close == nil {
+		return nil
+	}
+	return c.close()
+}
+
+// Healthcheck checks if the publisher can reach its target. For http, the target
+// set with cloudevents.WithTarget must respond; without a target, there is nothing to check.
+// For pubsub, the topic must exist.
+func (c *Client) Healthcheck(ctx context.Context) error {
+	if c.healthcheck == nil {
+		return nil
+	}
+	return c.healthcheck(ctx)
+}
+
+// httpHealthcheck checks that the target of p responds without a server error.
+func httpHealthcheck(p *cehttp.Protocol) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if p.Target == nil {
+			return nil
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodOptions, p.Target.String(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := p.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("got error code %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// topicHealthcheck checks that topic exists.
+func topicHealthcheck(topic *pubsub.Topic) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ok, err := topic.Exists(ctx)
+		if err != nil {
+			return errors.Wrap(err, "cannot check pubsub topic")
+		}
+		if !ok {
+			return fmt.Errorf("pubsub topic %s does not exist", topic.ID())
+		}
+		return nil
+	}
 }
 
 // EventarcToEvent converts event in Eventarc format to ce-event.
@@ -232,29 +356,9 @@ func NewEventFromHTTPRequest(ctx context.Context, r *http.Request, p Protocol) (
 
 	event, err := binding.ToEvent(ctx, m)
 
-	// parse spancontext
-	if spanContext, ok := event.Extensions()["spancontext"]; ok {
-		var sc = struct {
-			TraceID    string
-			SpanID     string
-			TraceFlags byte
-			TraceState string
-			Remote     bool
-		}{}
-
-		if scStr, ok := spanContext.(string); ok {
-			sDec, _ := base64.StdEncoding.DecodeString(scStr)
-			json.Unmarshal(sDec, &sc)
-		}
-
-		var spanContextConfig = trace.SpanContextConfig{}
-		spanContextConfig.TraceID, _ = trace.TraceIDFromHex(sc.TraceID)
-		spanContextConfig.SpanID, _ = trace.SpanIDFromHex(sc.SpanID)
-		spanContextConfig.TraceFlags = 01
-		spanContextConfig.Remote = sc.Remote
-
-		spanContext := trace.NewSpanContext(spanContextConfig)
-		ctx = trace.ContextWithRemoteSpanContext(ctx, spanContext)
+	// parse traceparent, or the legacy spancontext
+	if err == nil {
+		ctx = cetrace.Propagator{}.Extract(ctx, *event)
 	}
 
 	return ctx, event, err
@@ -0,0 +1,109 @@
+package cloudevents
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxKey struct{}
+
+func TestStartReceiverMiddleware(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+
+	// a stand-in for logger.HTTPMiddleware, setting the span of the request headers
+	middleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := trace.ContextWithRemoteSpanContext(r.Context(), trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: traceID,
+				SpanID:  spanID,
+			}))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	c := &Client{Protocol: HTTPProtocol}
+	c.WithPort(port).WithMiddleware(middleware)
+
+	received := make(chan context.Context, 1)
+	fn := func(ctx context.Context, e cloudevents.Event) (*cloudevents.Event, cloudevents.Result) {
+		received <- ctx
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "receiver"))
+	done := make(chan error, 1)
+	go func() { done <- c.StartReceiver(ctx, fn) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("StartReceiver() error = %v", err)
+		}
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/", port)
+	var resp *http.Response
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Ce-Specversion", "1.0")
+		req.Header.Set("Ce-Id", "1")
+		req.Header.Set("Ce-Source", "test")
+		req.Header.Set("Ce-Type", "test.event")
+		if resp, err = http.DefaultClient.Do(req); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cannot reach the receiver: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	select {
+	case ctx := <-received:
+		if sc := trace.SpanContextFromContext(ctx); sc.TraceID() != traceID || sc.SpanID() != spanID {
+			t.Errorf("fn span context = %s/%s, want the one of the middleware", sc.TraceID(), sc.SpanID())
+		}
+		if v := ctx.Value(ctxKey{}); v != "receiver" {
+			t.Errorf("fn context value = %v, want the value of the receiver context", v)
+		}
+	default:
+		t.Fatal("fn was not called")
+	}
+}
+
+func TestPubSubClose(t *testing.T) {
+	t.Setenv("PUBSUB_EMULATOR_HOST", "127.0.0.1:1")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "project")
+	t.Setenv("PUBSUB_TOPIC", "topic")
+
+	c, err := PubSub(context.Background())
+	if err != nil {
+		t.Fatalf("PubSub() error = %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if err := c.Close(); err == nil {
+		t.Error("Close() error = nil, want the pubsub client closed already")
+	}
+}
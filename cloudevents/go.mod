@@ -6,6 +6,7 @@ require (
 	cloud.google.com/go/pubsub v1.38.0
 	github.com/cloudevents/sdk-go/protocol/pubsub/v2 v2.15.2
 	github.com/cloudevents/sdk-go/v2 v2.15.2
+	github.com/onmi-bv/commons/internal/cetrace v0.0.0-00010101000000-000000000000
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.9.3
 	go.opentelemetry.io/otel/trace v1.27.0
@@ -51,3 +52,5 @@ require (
 	google.golang.org/grpc v1.63.2 // indirect
 	google.golang.org/protobuf v1.34.0 // indirect
 )
+
+replace github.com/onmi-bv/commons/internal/cetrace => ../internal/cetrace
@@ -0,0 +1,122 @@
+module github.com/onmi-bv/commons/cmd/commons-config
+
+go 1.19
+
+replace github.com/onmi-bv/commons/confighelper => ../../confighelper
+
+replace github.com/onmi-bv/commons/logger => ../../logger
+
+replace github.com/onmi-bv/commons/redis => ../../redis
+
+replace github.com/onmi-bv/commons/mongo => ../../mongo
+
+replace github.com/onmi-bv/commons/influx => ../../influx
+
+replace github.com/onmi-bv/commons/dgraph => ../../dgraph
+
+replace github.com/onmi-bv/commons/graphql => ../../graphql
+
+replace github.com/onmi-bv/commons/tracing => ../../tracing
+
+require (
+	github.com/onmi-bv/commons/confighelper v0.0.0-20230107122636-6b6bd027401a
+	github.com/onmi-bv/commons/dgraph v0.0.0-00010101000000-000000000000
+	github.com/onmi-bv/commons/graphql v0.0.0-00010101000000-000000000000
+	github.com/onmi-bv/commons/influx v0.0.0-00010101000000-000000000000
+	github.com/onmi-bv/commons/logger v0.0.0-00010101000000-000000000000
+	github.com/onmi-bv/commons/mongo v0.0.0-00010101000000-000000000000
+	github.com/onmi-bv/commons/redis v0.0.0-00010101000000-000000000000
+	github.com/onmi-bv/commons/tracing v0.0.0-00010101000000-000000000000
+)
+
+require (
+	cloud.google.com/go/compute v1.14.0 // indirect
+	cloud.google.com/go/compute/metadata v0.2.3 // indirect
+	cloud.google.com/go/trace v1.8.0 // indirect
+	github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace v1.11.0 // indirect
+	github.com/GoogleCloudPlatform/opentelemetry-operations-go/internal/resourcemapping v0.35.0 // indirect
+	github.com/cenkalti/backoff/v4 v4.2.0 // indirect
+	github.com/cespare/xxhash/v2 v2.1.2 // indirect
+	github.com/cloudevents/sdk-go/v2 v2.13.0 // indirect
+	github.com/dgraph-io/dgo/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/fsnotify/fsnotify v1.6.0 // indirect
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/go-redis/redis/v8 v8.11.5 // indirect
+	github.com/go-stack/stack v1.8.1 // indirect
+	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/golang/snappy v0.0.4 // indirect
+	github.com/google/go-cmp v0.5.9 // indirect
+	github.com/googleapis/enterprise-certificate-proxy v0.2.1 // indirect
+	github.com/googleapis/gax-go/v2 v2.7.0 // indirect
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.7.0 // indirect
+	github.com/hashicorp/hcl v1.0.0 // indirect
+	github.com/influxdata/influxdb v1.11.0 // indirect
+	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/klauspost/compress v1.14.2 // indirect
+	github.com/magiconair/properties v1.8.7 // indirect
+	github.com/mitchellh/go-homedir v1.1.0 // indirect
+	github.com/mitchellh/mapstructure v1.5.0 // indirect
+	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
+	github.com/montanaflynn/stats v0.0.0-20171201202039-1bf9dbcd8cbe // indirect
+	github.com/onmi-bv/commons/graphql/api v0.0.0-20230107122636-6b6bd027401a // indirect
+	github.com/onmi-bv/commons/internal/alerting v0.0.0-00010101000000-000000000000 // indirect
+	github.com/onmi-bv/commons/internal/cetrace v0.0.0-00010101000000-000000000000 // indirect
+	github.com/onmi-bv/commons/internal/slack v0.0.0-20230107122636-6b6bd027401a // indirect
+	github.com/onmi-bv/commons/internal/slackrus v0.0.0-20230107122636-6b6bd027401a // indirect
+	github.com/opentracing/opentracing-go v1.2.0 // indirect
+	github.com/pelletier/go-toml/v2 v2.0.6 // indirect
+	github.com/philhofer/fwd v1.1.2 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/sirupsen/logrus v1.9.0 // indirect
+	github.com/spf13/afero v1.9.3 // indirect
+	github.com/spf13/cast v1.5.0 // indirect
+	github.com/spf13/jwalterweatherman v1.1.0 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
+	github.com/spf13/viper v1.15.0 // indirect
+	github.com/subosito/gotenv v1.4.2 // indirect
+	github.com/tinylib/msgp v1.1.8 // indirect
+	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
+	github.com/xdg-go/scram v1.1.1 // indirect
+	github.com/xdg-go/stringprep v1.0.3 // indirect
+	github.com/youmark/pkcs8 v0.0.0-20181117223130-1be2e3e5546d // indirect
+	go.mongodb.org/mongo-driver v1.11.1 // indirect
+	go.opencensus.io v0.24.0 // indirect
+	go.opentelemetry.io/otel v1.12.0 // indirect
+	go.opentelemetry.io/otel/bridge/opentracing v1.12.0 // indirect
+	go.opentelemetry.io/otel/exporters/jaeger v1.12.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/internal/retry v1.12.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace v1.12.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.12.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.12.0 // indirect
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.12.0 // indirect
+	go.opentelemetry.io/otel/sdk v1.12.0 // indirect
+	go.opentelemetry.io/otel/trace v1.12.0 // indirect
+	go.opentelemetry.io/proto/otlp v0.19.0 // indirect
+	go.uber.org/atomic v1.10.0 // indirect
+	go.uber.org/multierr v1.8.0 // indirect
+	golang.org/x/crypto v0.0.0-20220622213112-05595931fe9d // indirect
+	golang.org/x/net v0.4.0 // indirect
+	golang.org/x/oauth2 v0.0.0-20221014153046-6fdb5e3db783 // indirect
+	golang.org/x/sync v0.1.0 // indirect
+	golang.org/x/sys v0.3.0 // indirect
+	golang.org/x/text v0.5.0 // indirect
+	google.golang.org/api v0.108.0 // indirect
+	google.golang.org/appengine v1.6.7 // indirect
+	google.golang.org/genproto v0.0.0-20230125152338-dcaf20b6aeaa // indirect
+	google.golang.org/grpc v1.52.3 // indirect
+	google.golang.org/protobuf v1.28.1 // indirect
+	gopkg.in/ini.v1 v1.67.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/onmi-bv/commons/internal/slackrus => ../../internal/slackrus
+
+replace github.com/onmi-bv/commons/internal/slack => ../../internal/slack
+
+replace github.com/onmi-bv/commons/internal/alerting => ../../internal/alerting
+
+replace github.com/onmi-bv/commons/internal/cetrace => ../../internal/cetrace
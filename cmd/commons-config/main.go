@@ -0,0 +1,134 @@
+// Command commons-config documents the configuration of the commons packages.
+// It prints a JSON Schema, a Markdown table or an example .env file listing
+// the environment variables of every package, with their types, defaults
+// and validation rules.
+//
+// Usage:
+//
+//	commons-config [-format schema|markdown|env] [-prefix PREFIX] [package ...]
+//
+// Without packages, all packages are documented with their default prefix.
+// The prefix can only be overridden when documenting a single package.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/onmi-bv/commons/confighelper"
+	"github.com/onmi-bv/commons/dgraph"
+	"github.com/onmi-bv/commons/graphql"
+	"github.com/onmi-bv/commons/influx"
+	"github.com/onmi-bv/commons/logger"
+	"github.com/onmi-bv/commons/mongo"
+	"github.com/onmi-bv/commons/redis"
+	"github.com/onmi-bv/commons/tracing"
+)
+
+// pkg is a documented package with the default prefix of its environment variables.
+type pkg struct {
+	Name   string
+	Prefix string
+	Config interface{}
+}
+
+var packages = []pkg{
+	{Name: "logger", Prefix: "LOG", Config: &logger.Logger{}},
+	{Name: "redis", Prefix: "REDIS", Config: &redis.Client{}},
+	{Name: "mongo", Prefix: "MONGO", Config: &mongo.Config{}},
+	{Name: "influx", Prefix: "INFLUX", Config: &influx.Config{}},
+	{Name: "dgraph", Prefix: "DGRAPH", Config: &dgraph.Client{}},
+	{Name: "graphql", Prefix: "GRAPHQL", Config: &graphql.Client{}},
+	{Name: "tracing", Prefix: "TRACING", Config: &tracing.Config{}},
+}
+
+// doc lists the configuration keys of a package.
+type doc struct {
+	Name   string
+	Prefix string
+	Keys   []confighelper.Key
+}
+
+var renderers = map[string]func(io.Writer, []doc) error{
+	"schema":   writeSchema,
+	"markdown": writeMarkdown,
+	"env":      writeEnv,
+}
+
+func main() {
+	format := flag.String("format", "markdown", "output format: schema, markdown or env")
+	prefix := flag.String("prefix", "", "prefix of the environment variables, defaults to the package prefix")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: commons-config [flags] [package ...]\n\npackages: %s\n\nflags:\n", strings.Join(names(), ", "))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(os.Stdout, *format, *prefix, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "commons-config:", err)
+		os.Exit(2)
+	}
+}
+
+func run(w io.Writer, format string, prefix string, args []string) error {
+	render, ok := renderers[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	selected, err := lookup(args)
+	if err != nil {
+		return err
+	}
+	if prefix != "" && len(selected) != 1 {
+		return fmt.Errorf("-prefix requires exactly one package")
+	}
+
+	docs := make([]doc, 0, len(selected))
+	for _, p := range selected {
+		if prefix != "" {
+			p.Prefix = prefix
+		}
+		docs = append(docs, doc{
+			Name:   p.Name,
+			Prefix: strings.ToUpper(p.Prefix),
+			Keys:   confighelper.Keys(p.Prefix, p.Config),
+		})
+	}
+
+	return render(w, docs)
+}
+
+// lookup returns the packages named by args, or all packages if args is empty.
+func lookup(args []string) ([]pkg, error) {
+	if len(args) == 0 {
+		return packages, nil
+	}
+
+	var selected []pkg
+	for _, name := range args {
+		found := false
+		for _, p := range packages {
+			if p.Name == name {
+				selected = append(selected, p)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown package %q, must be one of %s", name, strings.Join(names(), ", "))
+		}
+	}
+	return selected, nil
+}
+
+func names() []string {
+	n := make([]string, len(packages))
+	for i, p := range packages {
+		n[i] = p.Name
+	}
+	return n
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onmi-bv/commons/confighelper"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// writeSchema writes a JSON Schema of the environment variables in docs.
+func writeSchema(w io.Writer, docs []doc) error {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	for _, d := range docs {
+		for _, k := range d.Keys {
+			properties[k.Env] = propertySchema(k)
+			if k.Required && !k.HasDefault {
+				required = append(required, k.Env)
+			}
+		}
+	}
+
+	schema := map[string]interface{}{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      "commons configuration",
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
+
+// propertySchema returns the schema of a single key.
+func propertySchema(k confighelper.Key) map[string]interface{} {
+	s := typeSchema(k.Type)
+	s["description"] = k.Name
+
+	if k.HasDefault {
+		s["default"] = defaultValue(k.Type, k.Default)
+	}
+	if k.Secret {
+		s["writeOnly"] = true
+	}
+
+	for _, rule := range rules(k.Validate) {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "oneof":
+			opts := strings.Fields(arg)
+			if s["type"] == "array" {
+				s["items"].(map[string]interface{})["enum"] = opts
+			} else {
+				s["enum"] = opts
+			}
+		case "min", "max":
+			setBound(s, name, arg)
+		case "url":
+			s["format"] = "uri"
+		}
+	}
+	return s
+}
+
+// typeSchema maps t to a JSON Schema type.
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType {
+		return map[string]interface{}{"type": "string", "format": "duration"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+// setBound sets the minimum or maximum of s. Numbers are bound by value,
+// strings, arrays and objects by length. Duration bounds are not expressed.
+func setBound(s map[string]interface{}, name string, arg string) {
+	if s["format"] == "duration" {
+		return
+	}
+	n, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return
+	}
+
+	switch s["type"] {
+	case "integer", "number":
+		s[name+"imum"] = n
+	case "string":
+		s[name+"Length"] = n
+	case "array":
+		s[name+"Items"] = n
+	case "object":
+		s[name+"Properties"] = n
+	}
+}
+
+// defaultValue converts the default tag to the JSON type of t.
+func defaultValue(t reflect.Type, value string) interface{} {
+	switch typeSchema(t)["type"] {
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "array":
+		if value == "" {
+			return []string{}
+		}
+		return strings.Split(value, ",")
+	}
+	return value
+}
+
+// writeMarkdown writes a table of the environment variables per package.
+func writeMarkdown(w io.Writer, docs []doc) error {
+	for i, d := range docs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "## %s\n\n", d.Name)
+		fmt.Fprintln(w, "| Variable | Key | Type | Default | Required | Validation |")
+		fmt.Fprintln(w, "|---|---|---|---|---|---|")
+		for _, k := range d.Keys {
+			fmt.Fprintf(w, "| `%s` | %s | %s | %s | %s | %s |\n",
+				k.Env, k.Name, typeName(k), cell(defaultText(k)), yesNo(k.Required), cell(k.Validate))
+		}
+	}
+	return nil
+}
+
+// writeEnv writes an example .env file, setting every variable to its default.
+func writeEnv(w io.Writer, docs []doc) error {
+	for i, d := range docs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "# %s\n", d.Name)
+		for _, k := range d.Keys {
+			notes := []string{typeName(k)}
+			if k.Required {
+				notes = append(notes, "required")
+			}
+			if k.Validate != "" {
+				notes = append(notes, k.Validate)
+			}
+			fmt.Fprintf(w, "# %s\n", strings.Join(notes, ", "))
+
+			value := k.Default
+			if k.Secret {
+				value = ""
+			}
+			fmt.Fprintf(w, "%s=%s\n", k.Env, quote(value))
+		}
+	}
+	return nil
+}
+
+func typeName(k confighelper.Key) string {
+	name := k.Type.String()
+	if k.Secret {
+		name += " (secret)"
+	}
+	return name
+}
+
+func defaultText(k confighelper.Key) string {
+	if !k.HasDefault {
+		return ""
+	}
+	if k.Default == "" {
+		return `""`
+	}
+	return "`" + k.Default + "`"
+}
+
+// cell escapes the pipes of a table cell.
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// quote quotes values that gotenv would otherwise split or trim.
+func quote(value string) string {
+	if strings.ContainsAny(value, " #\"'") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// rules splits a validate tag into its rules.
+func rules(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	r := strings.Split(tag, ",")
+	for i := range r {
+		r[i] = strings.TrimSpace(r[i])
+	}
+	return r
+}
@@ -1,47 +1,102 @@
 package confighelper
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"reflect"
 	"strings"
+	"sync"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 )
 
-// BindEnvs binds the mapstructure to the environment variables
-func bindEnvs(iface interface{}, parts ...string) {
-	var ifv reflect.Value
-	var ift reflect.Type
-	if reflect.TypeOf(iface).Kind() == reflect.Struct {
-		ifv = reflect.ValueOf(iface)
-		ift = reflect.TypeOf(iface)
-	} else {
-		ifv = reflect.ValueOf(iface).Elem()
-		ift = reflect.TypeOf(iface).Elem()
+// ProfileEnv is the environment variable selecting the profile, i.e. dev, staging or prod.
+const ProfileEnv = "APP_PROFILE"
+
+// Loader loads configuration into structs using its own viper instance,
+// so loaders with different files or prefixes do not interfere.
+//
+// Values are taken from the following sources, each overriding the ones before:
+// default tags, the config file, the profile file, the .env file, environment
+// variables and overrides.
+type Loader struct {
+	cfgFile   string
+	prefix    string
+	profile   string
+	dotEnv    string
+	overrides map[string]interface{}
+
+	mu          sync.Mutex
+	v           *viper.Viper
+	resolvers   map[string]Resolver
+	profileKeys map[string]bool
+	dotEnvKeys  map[string]bool
+}
+
+// New creates a Loader configured by opts. Without WithFile, ~/.conf is searched
+// for a config file. Without WithProfile, the profile is read from APP_PROFILE.
+func New(opts ...Option) *Loader {
+	l := &Loader{
+		profile:   os.Getenv(ProfileEnv),
+		overrides: map[string]interface{}{},
+		v:         viper.New(),
+		resolvers: defaultResolvers(),
 	}
-	for i := 0; i < ift.NumField(); i++ {
-		v := ifv.Field(i)
-		t := ift.Field(i)
-		tv, ok := t.Tag.Lookup("mapstructure")
-		if !ok {
-			continue
-		}
-		switch v.Kind() {
-		case reflect.Struct:
-			bindEnvs(v.Interface(), append(parts, tv)...)
-		default:
-			viper.BindEnv(strings.Join(append(parts, tv), "."))
-		}
+	for _, opt := range opts {
+		opt(l)
 	}
+	return l
 }
 
-// ReadConfig loads the application configuration
-func ReadConfig(cfgFile string, prefix string, config interface{}) error {
-	if cfgFile != "" {
+// NewLoader creates a Loader reading from cfgFile and from environment variables
+// starting with prefix. If cfgFile is empty, ~/.conf is searched instead.
+func NewLoader(cfgFile string, prefix string) *Loader {
+	return New(WithFile(cfgFile), WithPrefix(prefix))
+}
+
+// Viper returns the viper instance used by the last load.
+func (l *Loader) Viper() *viper.Viper {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.v
+}
+
+// bindEnvs binds the mapstructure to the environment variables
+// and registers the values of default tags.
+func bindEnvs(v *viper.Viper, iface interface{}, parts ...string) {
+	walkFields(iface, func(f field) {
+		v.BindEnv(f.Key)
+		if def, ok := f.Field.Tag.Lookup("default"); ok {
+			v.SetDefault(f.Key, def)
+		}
+	}, parts...)
+}
+
+// Load reads the config file and environment into config.
+// Fields without a value get the value of their default tag. References such as
+// file:///run/secrets/password are replaced by the values their resolvers return.
+// The result is checked against the required and validate tags, and a
+// ValidationError lists every offending key.
+func (l *Loader) Load(config interface{}) error {
+	return l.LoadContext(context.Background(), config)
+}
+
+// LoadContext is like Load, passing ctx to the resolvers.
+func (l *Loader) LoadContext(ctx context.Context, config interface{}) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// every load starts from a fresh viper instance, so that
+	// values of files that were removed meanwhile do not stick.
+	v := viper.New()
+
+	if l.cfgFile != "" {
 		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
+		v.SetConfigFile(l.cfgFile)
 	} else {
 		// Find home directory.
 		home, err := homedir.Dir()
@@ -49,32 +104,80 @@ func ReadConfig(cfgFile string, prefix string, config interface{}) error {
 			return err
 		}
 
-		// Search config in home directory with name ".places" (without extension).
-		viper.AddConfigPath(home)
-		viper.SetConfigName(".conf")
+		// Search config in home directory with name ".conf" (without extension).
+		v.AddConfigPath(home)
+		v.SetConfigName(".conf")
 	}
 
-	viper.SetEnvPrefix(prefix)
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	viper.AutomaticEnv() // read in environment variables that match
+	v.SetEnvPrefix(l.prefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv() // read in environment variables that match
 
 	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
+	if err := v.ReadInConfig(); err != nil && !isConfigFileMissing(err) {
+		return fmt.Errorf("cannot read config file: %v", err)
+	}
+
+	// the profile and .env files are merged into the config file values
+	profileKeys, err := mergeProfile(v, l.profile)
+	if err != nil {
+		return err
+	}
+	dotEnvKeys, err := mergeDotEnv(v, l.dotEnv, l.prefix, config)
+	if err != nil {
 		return err
 	}
 
+	for key, value := range l.overrides {
+		v.Set(key, value)
+	}
+
 	// unmarshal
-	bindEnvs(config)
-	if err := viper.Unmarshal(config); err != nil {
+	bindEnvs(v, config)
+	if err := v.Unmarshal(config, decodeHook()); err != nil {
 		return err
 	}
 
-	return nil
+	l.v, l.profileKeys, l.dotEnvKeys = v, profileKeys, dotEnvKeys
+
+	if err := resolveFields(ctx, l.resolvers, l.prefix, config); err != nil {
+		return err
+	}
+
+	return validate(l.prefix, config)
 }
 
-// FatalGet gets env. variable and panics if not set
+// isConfigFileMissing reports whether err means there is no usable config file,
+// in which case the configuration is read from the environment only.
+func isConfigFileMissing(err error) bool {
+	var notFound viper.ConfigFileNotFoundError
+	var unsupported viper.UnsupportedConfigError
+	return errors.As(err, &notFound) || errors.As(err, &unsupported) || errors.Is(err, fs.ErrNotExist)
+}
+
+// SetDefaults sets the fields of config to the values of their default tags.
+func SetDefaults(config interface{}) error {
+	v := viper.New()
+	walkFields(config, func(f field) {
+		if def, ok := f.Field.Tag.Lookup("default"); ok {
+			v.SetDefault(f.Key, def)
+		}
+	})
+	return v.Unmarshal(config, decodeHook())
+}
+
+// ReadConfig loads the application configuration
+func ReadConfig(cfgFile string, prefix string, config interface{}) error {
+	return NewLoader(cfgFile, prefix).Load(config)
+}
+
+// FatalGet gets env. variable and panics if not set.
+// File and env references in the value are resolved.
 func FatalGet(env string, fallback string) string {
-	s := os.Getenv(env)
+	s, err := resolve(context.Background(), defaultResolvers(), os.Getenv(env))
+	if err != nil {
+		panic(fmt.Sprintf("cannot resolve %s: %v", env, err))
+	}
 	if s == "" {
 		if fallback == "" {
 			panic(fmt.Sprintf("%s not set", env))
@@ -85,10 +188,12 @@ func FatalGet(env string, fallback string) string {
 	return s
 }
 
-// GetEnv gets an env. variable without panicing
+// GetEnv gets an env. variable without panicing.
+// File and env references in the value are resolved, the fallback is
+// returned if that fails.
 func GetEnv(env string, fallback string) string {
-	s := os.Getenv(env)
-	if s == "" {
+	s, err := resolve(context.Background(), defaultResolvers(), os.Getenv(env))
+	if err != nil || s == "" {
 		return fallback
 	}
 	return s
@@ -0,0 +1,581 @@
+package confighelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	URL      string `mapstructure:"URL"`
+	Database int    `mapstructure:"DATABASE"`
+	Nested   struct {
+		Name string `mapstructure:"NAME"`
+	} `mapstructure:"NESTED"`
+}
+
+func TestLoaderPrefixes(t *testing.T) {
+	t.Setenv("REDIS_URL", "redis:6379")
+	t.Setenv("MONGO_URL", "mongodb://mongo:27017")
+	t.Setenv("MONGO_NESTED_NAME", "nested")
+
+	var redis, mongo testConfig
+	if err := NewLoader("", "redis").Load(&redis); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := NewLoader("", "mongo").Load(&mongo); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if redis.URL != "redis:6379" {
+		t.Errorf("redis URL = %q, want %q", redis.URL, "redis:6379")
+	}
+	if mongo.URL != "mongodb://mongo:27017" {
+		t.Errorf("mongo URL = %q, want %q", mongo.URL, "mongodb://mongo:27017")
+	}
+	if mongo.Nested.Name != "nested" {
+		t.Errorf("mongo nested name = %q, want %q", mongo.Nested.Name, "nested")
+	}
+	if redis.Nested.Name != "" {
+		t.Errorf("redis nested name = %q, want empty", redis.Nested.Name)
+	}
+}
+
+func TestLoaderConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("URL: from-file\nDATABASE: 2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FILE_DATABASE", "3")
+
+	var c testConfig
+	if err := ReadConfig(path, "file", &c); err != nil {
+		t.Fatalf("ReadConfig() error = %v", err)
+	}
+	if c.URL != "from-file" {
+		t.Errorf("URL = %q, want %q", c.URL, "from-file")
+	}
+	if c.Database != 3 {
+		t.Errorf("Database = %d, want env value 3", c.Database)
+	}
+}
+
+func TestLoaderMissingFile(t *testing.T) {
+	var c testConfig
+	if err := ReadConfig(filepath.Join(t.TempDir(), "missing.yaml"), "missing", &c); err != nil {
+		t.Errorf("ReadConfig() error = %v, want nil for a missing file", err)
+	}
+}
+
+func TestLoaderConcurrent(t *testing.T) {
+	const n = 8
+	for i := 0; i < n; i++ {
+		t.Setenv(fmt.Sprintf("CONC%d_DATABASE", i), fmt.Sprint(i))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var c testConfig
+			if err := ReadConfig("", fmt.Sprintf("conc%d", i), &c); err != nil {
+				errs <- err
+				return
+			}
+			if c.Database != i {
+				errs <- fmt.Errorf("prefix conc%d: Database = %d", i, c.Database)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+type taggedConfig struct {
+	URL      string        `mapstructure:"URL" required:"true" validate:"url"`
+	Level    string        `mapstructure:"LEVEL" default:"info" validate:"oneof=debug info warning"`
+	Port     int           `mapstructure:"PORT" default:"8080" validate:"min=1,max=65535"`
+	Timeout  time.Duration `mapstructure:"TIMEOUT" default:"5s" validate:"max=1m"`
+	Optional string        `mapstructure:"OPTIONAL" validate:"url"`
+}
+
+func TestLoaderDefaults(t *testing.T) {
+	t.Setenv("DEF_URL", "http://localhost")
+
+	var c taggedConfig
+	if err := ReadConfig("", "def", &c); err != nil {
+		t.Fatalf("ReadConfig() error = %v", err)
+	}
+	if c.Level != "info" || c.Port != 8080 || c.Timeout != 5*time.Second {
+		t.Errorf("defaults not applied: %+v", c)
+	}
+}
+
+func TestLoaderValidation(t *testing.T) {
+	t.Setenv("VAL_LEVEL", "verbose")
+	t.Setenv("VAL_PORT", "0")
+	t.Setenv("VAL_TIMEOUT", "2m")
+	t.Setenv("VAL_OPTIONAL", "not a url")
+
+	var c taggedConfig
+	err := ReadConfig("", "val", &c)
+
+	var verr ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("ReadConfig() error = %v, want ValidationError", err)
+	}
+
+	got := map[string]bool{}
+	for _, f := range verr {
+		got[f.Env] = true
+	}
+	for _, env := range []string{"VAL_URL", "VAL_LEVEL", "VAL_PORT", "VAL_TIMEOUT", "VAL_OPTIONAL"} {
+		if !got[env] {
+			t.Errorf("missing error for %s in %v", env, err)
+		}
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	var c taggedConfig
+	if err := SetDefaults(&c); err != nil {
+		t.Fatalf("SetDefaults() error = %v", err)
+	}
+	if c.Level != "info" || c.Port != 8080 || c.Timeout != 5*time.Second || c.URL != "" {
+		t.Errorf("SetDefaults() = %+v", c)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	keys := Keys("doc", &taggedConfig{})
+
+	want := []Key{
+		{Name: "URL", Env: "DOC_URL", Type: reflect.TypeOf(""), Required: true, Validate: "url"},
+		{Name: "LEVEL", Env: "DOC_LEVEL", Type: reflect.TypeOf(""), Default: "info", HasDefault: true, Validate: "oneof=debug info warning"},
+		{Name: "PORT", Env: "DOC_PORT", Type: reflect.TypeOf(0), Default: "8080", HasDefault: true, Validate: "min=1,max=65535"},
+		{Name: "TIMEOUT", Env: "DOC_TIMEOUT", Type: reflect.TypeOf(time.Duration(0)), Default: "5s", HasDefault: true, Validate: "max=1m"},
+		{Name: "OPTIONAL", Env: "DOC_OPTIONAL", Type: reflect.TypeOf(""), Validate: "url"},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("Keys() = %+v, want %+v", keys, want)
+	}
+}
+
+func TestLoaderDescribe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("PORT: 9090\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DESC_URL", "http://localhost")
+	t.Setenv("DESC_PASSWORD", "hunter2")
+
+	var c struct {
+		URL      string `mapstructure:"URL" required:"true"`
+		Port     int    `mapstructure:"PORT" default:"8080"`
+		Level    string `mapstructure:"LEVEL" default:"info"`
+		Optional string `mapstructure:"OPTIONAL"`
+		Password string `mapstructure:"PASSWORD" secret:"true"`
+		Token    string `mapstructure:"TOKEN" secret:"true"`
+	}
+	l := NewLoader(path, "desc")
+	if err := l.Load(&c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]Setting{
+		"URL":      {Value: "http://localhost", Source: SourceEnv},
+		"PORT":     {Value: "9090", Source: SourceFile},
+		"LEVEL":    {Value: "info", Source: SourceDefault},
+		"OPTIONAL": {Value: "", Source: SourceUnset},
+		"PASSWORD": {Value: "***", Source: SourceEnv},
+		"TOKEN":    {Value: "<empty>", Source: SourceUnset},
+	}
+	d := l.Describe(&c)
+	for _, s := range d {
+		w, ok := want[s.Key]
+		if !ok {
+			continue
+		}
+		if s.Value != w.Value || s.Source != w.Source {
+			t.Errorf("Describe() %s = %q (%s), want %q (%s)", s.Key, s.Value, s.Source, w.Value, w.Source)
+		}
+		delete(want, s.Key)
+	}
+	for k := range want {
+		t.Errorf("Describe() is missing key %s", k)
+	}
+	if strings.Contains(d.String(), "hunter2") {
+		t.Errorf("Describe() leaks secret:\n%s", d)
+	}
+}
+
+// fakeSecretManager is a file-backed stand-in for a secret manager,
+// resolving secret://name to the content of dir/name.
+type fakeSecretManager struct {
+	dir string
+}
+
+func (m fakeSecretManager) Resolve(ctx context.Context, name string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(m.dir, name))
+	if err != nil {
+		return "", fmt.Errorf("secret %s not found", name)
+	}
+	return string(b), nil
+}
+
+func TestLoaderResolvers(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "redis-password"), []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "api-token"), []byte("from-manager"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("RES_PASSWORD", "file://"+filepath.Join(dir, "redis-password"))
+	t.Setenv("RES_USER", "env://OTHER_USER")
+	t.Setenv("OTHER_USER", "from-env")
+	t.Setenv("RES_TOKEN", "secret://api-token")
+	t.Setenv("RES_HOST", "http://localhost")
+
+	var c struct {
+		Password string `mapstructure:"PASSWORD" secret:"true"`
+		User     string `mapstructure:"USER"`
+		Token    string `mapstructure:"TOKEN" secret:"true"`
+		Host     string `mapstructure:"HOST"`
+	}
+	l := NewLoader("", "res")
+	l.SetResolver("secret", fakeSecretManager{dir})
+	if err := l.Load(&c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if c.Password != "from-file" || c.User != "from-env" || c.Token != "from-manager" || c.Host != "http://localhost" {
+		t.Errorf("Load() = %+v", c)
+	}
+
+	err := NewLoader("", "res").Load(&c)
+	if err == nil || !strings.Contains(err.Error(), "RES_TOKEN") {
+		t.Errorf("Load() without secret resolver error = %v, want error for RES_TOKEN", err)
+	}
+
+	t.Setenv("RES_TOKEN", "secret://missing")
+	l = NewLoader("", "res")
+	l.SetResolver("secret", fakeSecretManager{dir})
+	err = l.Load(&c)
+	if err == nil || !strings.Contains(err.Error(), "RES_TOKEN") {
+		t.Errorf("Load() with missing secret error = %v, want error for RES_TOKEN", err)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "api-token"), []byte("from-manager"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OPT_TOKEN", "secret://api-token")
+	t.Setenv("OPT_HOST", "from-env")
+
+	var c struct {
+		Token string `mapstructure:"TOKEN" secret:"true"`
+		Host  string `mapstructure:"HOST"`
+	}
+	l := New(Options("", "opt", fakeSecretManager{dir}, WithOverride("HOST", "from-source"))...)
+	if err := l.Load(&c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c.Token != "from-manager" || c.Host != "from-source" {
+		t.Errorf("Load() = %+v, want the secret resolved and the source applied", c)
+	}
+
+	if err := New(Options("", "opt", nil)...).Load(&c); err == nil {
+		t.Error("Load() without secrets error = nil, want error for OPT_TOKEN")
+	}
+}
+
+func TestLoaderResolversCollections(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "api-token"), []byte("Bearer from-manager"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("COL_HEADERS", "authorization=secret://api-token,x-team=core")
+	t.Setenv("COL_TOKENS", "secret://api-token,plain")
+
+	var c struct {
+		Headers map[string]string `mapstructure:"HEADERS" secret:"true"`
+		Tokens  []string          `mapstructure:"TOKENS" secret:"true"`
+	}
+	l := NewLoader("", "col")
+	l.SetResolver("secret", fakeSecretManager{dir})
+	if err := l.Load(&c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if c.Headers["authorization"] != "Bearer from-manager" || c.Headers["x-team"] != "core" {
+		t.Errorf("Load() headers = %v, want the resolved secret", c.Headers)
+	}
+	if len(c.Tokens) != 2 || c.Tokens[0] != "Bearer from-manager" || c.Tokens[1] != "plain" {
+		t.Errorf("Load() tokens = %v, want the resolved secret", c.Tokens)
+	}
+
+	t.Setenv("COL_HEADERS", "authorization=secret://missing")
+	err := l.Load(&c)
+	if err == nil || !strings.Contains(err.Error(), "COL_HEADERS") {
+		t.Errorf("Load() with missing secret error = %v, want error for COL_HEADERS", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("URL: http://first\nLEVEL: info\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewLoader(path, "watch")
+	var c taggedConfig
+	if err := l.Load(&c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	w, err := Watch(l, c, func() taggedConfig { return taggedConfig{} })
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer w.Close()
+
+	// writing a file may fire several events, so never block the watcher
+	changes := make(chan Change[taggedConfig], 16)
+	errs := make(chan error, 16)
+	w.Subscribe(func(c Change[taggedConfig]) {
+		select {
+		case changes <- c:
+		default:
+		}
+	})
+	w.OnError(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+
+	// invalid configurations are reported and dropped
+	if err := os.WriteFile(path, []byte("URL: http://first\nLEVEL: verbose\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for found := false; !found; {
+		select {
+		case err := <-errs:
+			found = strings.Contains(err.Error(), "WATCH_LEVEL")
+		case c := <-changes:
+			t.Fatalf("Subscribe() got change %v for invalid config", c.Keys)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for validation error")
+		}
+	}
+	if w.Current().Level != "info" {
+		t.Errorf("Current().Level = %s, want info", w.Current().Level)
+	}
+
+	if err := os.WriteFile(path, []byte("URL: http://first\nLEVEL: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case c := <-changes:
+		if !c.Has("LEVEL") || c.Has("URL") {
+			t.Errorf("Change.Keys = %v, want [LEVEL]", c.Keys)
+		}
+		if c.Old.Level != "info" || c.New.Level != "debug" {
+			t.Errorf("Change level = %s -> %s, want info -> debug", c.Old.Level, c.New.Level)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for change")
+	}
+
+	if w.Current().Level != "debug" {
+		t.Errorf("Current().Level = %s, want debug", w.Current().Level)
+	}
+}
+
+func TestWatchClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("URL: http://first\nLEVEL: info\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewLoader(path, "watch")
+	var c taggedConfig
+	if err := l.Load(&c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	w, err := Watch(l, c, func() taggedConfig { return taggedConfig{} })
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	changes := make(chan Change[taggedConfig], 16)
+	w.Subscribe(func(c Change[taggedConfig]) {
+		select {
+		case changes <- c:
+		default:
+		}
+	})
+
+	// every reload replaces the viper instance of the loader, the watch keeps going
+	for _, level := range []string{"debug", "warning"} {
+		if err := os.WriteFile(path, []byte("URL: http://first\nLEVEL: "+level+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		for found := false; !found; {
+			select {
+			case c := <-changes:
+				found = c.New.Level == level
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timeout waiting for change to %s", level)
+			}
+		}
+	}
+
+	w.Close()
+	if err := os.WriteFile(path, []byte("URL: http://first\nLEVEL: info\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case c := <-changes:
+		t.Errorf("Subscribe() got change %v after Close", c.Keys)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if w.Current().Level != "warning" {
+		t.Errorf("Current().Level = %s, want warning", w.Current().Level)
+	}
+}
+
+func TestLoaderKinds(t *testing.T) {
+	type TLS struct {
+		Enabled bool   `mapstructure:"ENABLED"`
+		CAFile  string `mapstructure:"CA_FILE" required:"true"`
+	}
+	type Common struct {
+		Name string `mapstructure:"NAME"`
+	}
+	type kindsConfig struct {
+		Common   `mapstructure:",squash"`
+		Addrs    []string          `mapstructure:"ADDRS" required:"true"`
+		Ports    []int             `mapstructure:"PORTS"`
+		Labels   map[string]string `mapstructure:"LABELS"`
+		Headers  map[string]string `mapstructure:"HEADERS"`
+		Timeout  time.Duration     `mapstructure:"TIMEOUT" default:"1s"`
+		TLS      *TLS              `mapstructure:"TLS"`
+		Optional *TLS              `mapstructure:"OPTIONAL"`
+	}
+
+	t.Setenv("KINDS_NAME", "squashed")
+	t.Setenv("KINDS_ADDRS", "a:1,b:2")
+	t.Setenv("KINDS_PORTS", "[80, 443]")
+	t.Setenv("KINDS_LABELS", "env=dev, team=core")
+	t.Setenv("KINDS_HEADERS", `{"Authorization": "Bearer x"}`)
+	t.Setenv("KINDS_TIMEOUT", "1m30s")
+	t.Setenv("KINDS_TLS_ENABLED", "true")
+	t.Setenv("KINDS_TLS_CA_FILE", "/etc/ca.pem")
+
+	var c kindsConfig
+	if err := ReadConfig("", "kinds", &c); err != nil {
+		t.Fatalf("ReadConfig() error = %v", err)
+	}
+
+	want := kindsConfig{
+		Common:  Common{Name: "squashed"},
+		Addrs:   []string{"a:1", "b:2"},
+		Ports:   []int{80, 443},
+		Labels:  map[string]string{"env": "dev", "team": "core"},
+		Headers: map[string]string{"Authorization": "Bearer x"},
+		Timeout: 90 * time.Second,
+		TLS:     &TLS{Enabled: true, CAFile: "/etc/ca.pem"},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("ReadConfig() = %+v, want %+v", c, want)
+	}
+}
+
+func TestLoaderLayers(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	type layersConfig struct {
+		Default  string `mapstructure:"DEFAULT" default:"default"`
+		File     string `mapstructure:"FILE" default:"default"`
+		Profile  string `mapstructure:"PROFILE" default:"default"`
+		DotEnv   string `mapstructure:"DOTENV" default:"default"`
+		Env      string `mapstructure:"ENV" default:"default"`
+		Override string `mapstructure:"OVERRIDE" default:"default"`
+	}
+
+	base := write("app.yaml", "FILE: file\nPROFILE: file\nDOTENV: file\nENV: file\nOVERRIDE: file\n")
+	write("app.dev.yaml", "PROFILE: profile\nDOTENV: profile\nENV: profile\nOVERRIDE: profile\n")
+	write("app.prod.yaml", "PROFILE: prod\n")
+	dotEnv := write(".env", "LAYERS_DOTENV=dotenv\nLAYERS_ENV=dotenv\nLAYERS_OVERRIDE=dotenv\n")
+	t.Setenv("LAYERS_ENV", "env")
+	t.Setenv("LAYERS_OVERRIDE", "env")
+	t.Setenv(ProfileEnv, "dev")
+
+	l := New(
+		WithFile(base),
+		WithPrefix("layers"),
+		WithDotEnv(dotEnv),
+		WithOverride("OVERRIDE", "override"),
+	)
+
+	var c layersConfig
+	if err := l.Load(&c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := layersConfig{"default", "file", "profile", "dotenv", "env", "override"}
+	if c != want {
+		t.Errorf("Load() = %+v, want %+v", c, want)
+	}
+
+	sources := map[string]Source{}
+	for _, s := range l.Describe(&c) {
+		sources[s.Key] = s.Source
+	}
+	wantSources := map[string]Source{
+		"DEFAULT":  SourceDefault,
+		"FILE":     SourceFile,
+		"PROFILE":  SourceProfile,
+		"DOTENV":   SourceDotEnv,
+		"ENV":      SourceEnv,
+		"OVERRIDE": SourceOverride,
+	}
+	if !reflect.DeepEqual(sources, wantSources) {
+		t.Errorf("Describe() sources = %v, want %v", sources, wantSources)
+	}
+
+	// an explicit profile wins over APP_PROFILE
+	c = layersConfig{}
+	if err := New(WithFile(base), WithPrefix("layers"), WithProfile("prod")).Load(&c); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c.Profile != "prod" || c.DotEnv != "file" {
+		t.Errorf("Load() with prod profile = %+v", c)
+	}
+}
@@ -0,0 +1,70 @@
+package confighelper
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// decodeHook converts string values, as read from the environment, into the field types.
+// Slices and maps are decoded from JSON, or else from comma separated values,
+// i.e. a,b,c for slices and k1=v1,k2=v2 for maps. Durations are parsed with time.ParseDuration.
+func decodeHook() viper.DecoderConfigOption {
+	return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		stringToJSONHookFunc(),
+		stringToMapHookFunc(","),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))
+}
+
+// stringToJSONHookFunc decodes JSON arrays and objects into slices and maps.
+func stringToJSONHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Map {
+			return data, nil
+		}
+
+		s := strings.TrimSpace(data.(string))
+		if !strings.HasPrefix(s, "[") && !strings.HasPrefix(s, "{") {
+			return data, nil
+		}
+
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("cannot decode json %q: %v", s, err)
+		}
+		return v, nil
+	}
+}
+
+// stringToMapHookFunc decodes key=value pairs separated by sep into maps.
+func stringToMapHookFunc(sep string) mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t.Kind() != reflect.Map {
+			return data, nil
+		}
+
+		m := map[string]interface{}{}
+		s := data.(string)
+		if s == "" {
+			return m, nil
+		}
+
+		for _, pair := range strings.Split(s, sep) {
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("cannot decode %q as key=value", pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		return m, nil
+	}
+}
@@ -0,0 +1,149 @@
+package confighelper
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Source tells where the effective value of a key came from.
+type Source string
+
+// Configuration sources.
+const (
+	SourceUnknown  Source = ""
+	SourceUnset    Source = "unset"
+	SourceDefault  Source = "default"
+	SourceFile     Source = "file"
+	SourceProfile  Source = "profile"
+	SourceDotEnv   Source = "dotenv"
+	SourceEnv      Source = "env"
+	SourceOverride Source = "override"
+)
+
+// Setting describes the effective value of a configuration key.
+type Setting struct {
+	Key    string // Key is the configuration key, i.e. URL
+	Env    string // Env is the environment variable for the key, i.e. REDIS_URL
+	Value  string // Value is the effective value, masked for secrets.
+	Secret bool   // Secret is set for fields tagged with secret:"true".
+	Source Source // Source tells where the value came from.
+}
+
+// Description lists the settings of a configuration.
+type Description []Setting
+
+// String renders the description as a table.
+func (d Description) String() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tENV\tVALUE\tSOURCE")
+	for _, s := range d {
+		source := s.Source
+		if source == SourceUnknown {
+			source = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Key, s.Env, s.Value, source)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// Fields returns the description as structured log fields,
+// mapping every key to its value and source.
+func (d Description) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(d))
+	for _, s := range d {
+		if s.Source == SourceUnknown {
+			fields[s.Key] = s.Value
+			continue
+		}
+		fields[s.Key] = fmt.Sprintf("%s (%s)", s.Value, s.Source)
+	}
+	return fields
+}
+
+// Describe lists the effective configuration of config, masking the fields tagged
+// with secret:"true". It has no knowledge of sources; use Loader.Describe after
+// loading to include them.
+func Describe(config interface{}) Description {
+	return describe("", config, func(field) Source { return SourceUnknown })
+}
+
+// Describe lists the effective configuration of config as loaded by l,
+// including the source of every value. Fields tagged with secret:"true" are masked.
+func (l *Loader) Describe(config interface{}) Description {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return describe(l.prefix, config, func(f field) Source {
+		key := strings.ToLower(f.Key)
+		switch {
+		case l.hasOverride(key):
+			return SourceOverride
+		case os.Getenv(envName(l.prefix, f.Key)) != "":
+			return SourceEnv
+		case l.dotEnvKeys[key]:
+			return SourceDotEnv
+		case l.profileKeys[key]:
+			return SourceProfile
+		case l.v.InConfig(f.Key):
+			return SourceFile
+		}
+		if _, ok := f.Field.Tag.Lookup("default"); ok || !f.Value.IsZero() {
+			return SourceDefault
+		}
+		return SourceUnset
+	})
+}
+
+func (l *Loader) hasOverride(key string) bool {
+	for k := range l.overrides {
+		if strings.ToLower(k) == key {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(prefix string, config interface{}, source func(field) Source) Description {
+	var d Description
+
+	walkFields(config, func(f field) {
+		s := Setting{
+			Key:    f.Key,
+			Env:    envName(prefix, f.Key),
+			Value:  formatValue(f.Value),
+			Source: source(f),
+		}
+		if secret, _ := strconv.ParseBool(f.Field.Tag.Get("secret")); secret {
+			s.Secret = true
+			s.Value = maskSecret(f)
+		}
+		d = append(d, s)
+	})
+
+	return d
+}
+
+// formatValue formats v, showing the value pointers point to.
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "<nil>"
+		}
+		v = v.Elem()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func maskSecret(f field) string {
+	if isEmpty(f.Value) {
+		return "<empty>"
+	}
+	return "***"
+}
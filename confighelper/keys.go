@@ -0,0 +1,39 @@
+package confighelper
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Key documents a configuration key.
+type Key struct {
+	Name       string       // Name is the configuration key, i.e. SLACK.HOOK_URL
+	Env        string       // Env is the environment variable, i.e. LOG_SLACK_HOOK_URL
+	Type       reflect.Type // Type is the field type.
+	Default    string       // Default is the value of the default tag.
+	HasDefault bool         // HasDefault is set if the field has a default tag.
+	Required   bool         // Required is set for fields tagged with required:"true".
+	Secret     bool         // Secret is set for fields tagged with secret:"true".
+	Validate   string       // Validate holds the validation rules, i.e. min=1,max=10
+}
+
+// Keys lists the configuration keys of config, with the environment
+// variables they are bound to for prefix.
+func Keys(prefix string, config interface{}) []Key {
+	var keys []Key
+
+	walkFields(config, func(f field) {
+		k := Key{
+			Name:     f.Key,
+			Env:      envName(prefix, f.Key),
+			Type:     f.Field.Type,
+			Validate: f.Field.Tag.Get("validate"),
+		}
+		k.Default, k.HasDefault = f.Field.Tag.Lookup("default")
+		k.Required, _ = strconv.ParseBool(f.Field.Tag.Get("required"))
+		k.Secret, _ = strconv.ParseBool(f.Field.Tag.Get("secret"))
+		keys = append(keys, k)
+	})
+
+	return keys
+}
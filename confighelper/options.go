@@ -0,0 +1,148 @@
+package confighelper
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/subosito/gotenv"
+)
+
+// Option configures a Loader.
+type Option func(*Loader)
+
+// WithFile sets the base config file.
+func WithFile(path string) Option {
+	return func(l *Loader) {
+		l.cfgFile = path
+	}
+}
+
+// WithPrefix sets the prefix of the environment variables, i.e. REDIS for REDIS_URL.
+func WithPrefix(prefix string) Option {
+	return func(l *Loader) {
+		l.prefix = prefix
+	}
+}
+
+// WithProfile sets the profile, overriding APP_PROFILE. The profile file is named
+// after the base config file, i.e. app.dev.yaml for app.yaml and profile dev.
+func WithProfile(profile string) Option {
+	return func(l *Loader) {
+		l.profile = profile
+	}
+}
+
+// WithDotEnv sets a .env file with environment variables, i.e. REDIS_URL=redis:6379.
+// Its values override the config files, but not the environment.
+func WithDotEnv(path string) Option {
+	return func(l *Loader) {
+		l.dotEnv = path
+	}
+}
+
+// WithOverride sets the value of a key, i.e. URL or SLACK.HOOK_URL,
+// overriding all other sources.
+func WithOverride(key string, value interface{}) Option {
+	return func(l *Loader) {
+		l.overrides[key] = value
+	}
+}
+
+// WithResolver sets the resolver for references with the given scheme.
+func WithResolver(scheme string, r Resolver) Option {
+	return func(l *Loader) {
+		l.resolvers[scheme] = r
+	}
+}
+
+// Options returns the options of a package configuration: the config file at path,
+// the prefix, the resolver of secret:// references if not nil, then the layered sources.
+func Options(path string, prefix string, secrets Resolver, sources ...Option) []Option {
+	opts := []Option{
+		WithFile(path),
+		WithPrefix(prefix),
+	}
+	if secrets != nil {
+		opts = append(opts, WithResolver("secret", secrets))
+	}
+	return append(opts, sources...)
+}
+
+// mergeProfile merges the profile file next to the config file of v into v.
+// It returns the keys set by the profile file.
+func mergeProfile(v *viper.Viper, profile string) (map[string]bool, error) {
+	base := v.ConfigFileUsed()
+	if profile == "" || base == "" {
+		return nil, nil
+	}
+
+	ext := filepath.Ext(base)
+	pv := viper.New()
+	pv.SetConfigFile(strings.TrimSuffix(base, ext) + "." + profile + ext)
+
+	if err := pv.ReadInConfig(); err != nil {
+		if isConfigFileMissing(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read profile file: %v", err)
+	}
+	if err := v.MergeConfigMap(pv.AllSettings()); err != nil {
+		return nil, fmt.Errorf("cannot merge profile file: %v", err)
+	}
+
+	keys := map[string]bool{}
+	for _, k := range pv.AllKeys() {
+		keys[k] = true
+	}
+	return keys, nil
+}
+
+// mergeDotEnv merges the variables of the .env file at path, that match the keys
+// of config, into v. It returns the keys set by the .env file.
+func mergeDotEnv(v *viper.Viper, path string, prefix string, config interface{}) (map[string]bool, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	env, err := gotenv.Read(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read .env file: %v", err)
+	}
+
+	values := map[string]interface{}{}
+	keys := map[string]bool{}
+
+	walkFields(config, func(f field) {
+		value, ok := env[envName(prefix, f.Key)]
+		if !ok {
+			return
+		}
+
+		// nest the value below its parent keys
+		parts := strings.Split(f.Key, ".")
+		m := values
+		for _, p := range parts[:len(parts)-1] {
+			next, ok := m[p].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				m[p] = next
+			}
+			m = next
+		}
+		m[parts[len(parts)-1]] = value
+
+		keys[strings.ToLower(f.Key)] = true
+	})
+
+	if err := v.MergeConfigMap(values); err != nil {
+		return nil, fmt.Errorf("cannot merge .env file: %v", err)
+	}
+	return keys, nil
+}
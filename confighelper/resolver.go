@@ -0,0 +1,135 @@
+package confighelper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Resolver resolves a value reference to the referenced value.
+// The reference is passed without its scheme, i.e. for file:///run/secrets/password
+// the file resolver receives /run/secrets/password.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ResolverFunc is an adapter to use ordinary functions as Resolver.
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve calls f(ctx, ref).
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// FileResolver resolves file:// references to the content of the file,
+// without trailing newlines. Use it for secrets mounted as files.
+var FileResolver = ResolverFunc(func(ctx context.Context, ref string) (string, error) {
+	b, err := os.ReadFile(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+})
+
+// EnvResolver resolves env:// references to the value of another environment variable.
+var EnvResolver = ResolverFunc(func(ctx context.Context, ref string) (string, error) {
+	v, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("%s not set", ref)
+	}
+	return v, nil
+})
+
+// noSecretResolver fails secret:// references until a resolver is set,
+// so that they never end up as literal values.
+var noSecretResolver = ResolverFunc(func(ctx context.Context, ref string) (string, error) {
+	return "", fmt.Errorf("no resolver set for secret://%s", ref)
+})
+
+// defaultResolvers returns the resolvers available to every Loader.
+// The secret scheme has to be set with SetResolver.
+func defaultResolvers() map[string]Resolver {
+	return map[string]Resolver{
+		"file":   FileResolver,
+		"env":    EnvResolver,
+		"secret": noSecretResolver,
+	}
+}
+
+// SetResolver sets the resolver for references with the given scheme,
+// i.e. SetResolver("secret", r) resolves secret://name values using r.
+func (l *Loader) SetResolver(scheme string, r Resolver) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.resolvers[scheme] = r
+}
+
+// resolve resolves a single value. Values without a known scheme are returned as is.
+func resolve(ctx context.Context, resolvers map[string]Resolver, value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, "://")
+	if !ok {
+		return value, nil
+	}
+	r, ok := resolvers[scheme]
+	if !ok {
+		return value, nil
+	}
+	return r.Resolve(ctx, ref)
+}
+
+// resolveFields replaces the references in the string fields of config, and in the
+// string elements of slice and map fields, with their values.
+func resolveFields(ctx context.Context, resolvers map[string]Resolver, prefix string, config interface{}) error {
+	var errs ValidationError
+
+	walkFields(config, func(f field) {
+		if !f.Value.CanSet() {
+			return
+		}
+		if err := resolveValue(ctx, resolvers, f.Value); err != nil {
+			errs = append(errs, FieldError{
+				Key:    f.Key,
+				Env:    envName(prefix, f.Key),
+				Reason: fmt.Sprintf("cannot be resolved: %v", err),
+			})
+		}
+	})
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// resolveValue resolves a string, or the string elements of a slice or map.
+func resolveValue(ctx context.Context, resolvers map[string]Resolver, v reflect.Value) error {
+	switch {
+	case v.Kind() == reflect.String:
+		s, err := resolve(ctx, resolvers, v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveValue(ctx, resolvers, v.Index(i)); err != nil {
+				return err
+			}
+		}
+
+	case v.Kind() == reflect.Map && v.Type().Elem().Kind() == reflect.String:
+		iter := v.MapRange()
+		for iter.Next() {
+			s, err := resolve(ctx, resolvers, iter.Value().String())
+			if err != nil {
+				return fmt.Errorf("%v: %v", iter.Key(), err)
+			}
+			v.SetMapIndex(iter.Key(), reflect.ValueOf(s).Convert(v.Type().Elem()))
+		}
+	}
+	return nil
+}
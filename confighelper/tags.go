@@ -0,0 +1,245 @@
+package confighelper
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field is a leaf configuration field tagged with mapstructure.
+type field struct {
+	Key   string              // Key is the dotted viper key, i.e. SLACK.HOOK_URL
+	Value reflect.Value       // Value is the current field value.
+	Field reflect.StructField // Field is the struct field holding the value.
+	Nil   bool                // Nil is set for fields below a nil struct pointer.
+}
+
+// walkFields calls fn for every leaf field of iface tagged with mapstructure, except "-".
+// Nested structs and struct pointers are walked recursively, with their tag as
+// key prefix. Embedded structs tagged with ",squash" share the key prefix of
+// their parent. Fields below nil struct pointers are walked with zero values.
+func walkFields(iface interface{}, fn func(f field), parts ...string) {
+	walkValue(reflect.ValueOf(iface), false, fn, parts...)
+}
+
+func walkValue(ifv reflect.Value, isNil bool, fn func(f field), parts ...string) {
+	for ifv.Kind() == reflect.Ptr || ifv.Kind() == reflect.Interface {
+		ifv = ifv.Elem()
+	}
+	if ifv.Kind() != reflect.Struct {
+		return
+	}
+	ift := ifv.Type()
+
+	for i := 0; i < ift.NumField(); i++ {
+		v := ifv.Field(i)
+		t := ift.Field(i)
+		tv, ok := t.Tag.Lookup("mapstructure")
+		if !ok || tv == "-" || !t.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tv, ",")
+		key := parts
+		if !(t.Anonymous && strings.Contains(opts, "squash")) {
+			key = append(parts[:len(parts):len(parts)], name)
+		}
+
+		switch {
+		case v.Kind() == reflect.Struct:
+			walkValue(v, isNil, fn, key...)
+		case v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct:
+			if v.IsNil() {
+				walkValue(reflect.Zero(v.Type().Elem()), true, fn, key...)
+			} else {
+				walkValue(v, isNil, fn, key...)
+			}
+		default:
+			fn(field{Key: strings.Join(key, "."), Value: v, Field: t, Nil: isNil})
+		}
+	}
+}
+
+// FieldError describes a configuration key that is missing or invalid.
+type FieldError struct {
+	Key    string // Key is the configuration key, i.e. URL
+	Env    string // Env is the environment variable for the key, i.e. REDIS_URL
+	Reason string // Reason describes what is wrong with the value.
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s %s", e.Env, e.Reason)
+}
+
+// ValidationError lists every missing or invalid key of a configuration.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, f := range e {
+		msgs[i] = f.Error()
+	}
+	return "invalid configuration: " + strings.Join(msgs, "; ")
+}
+
+// envName returns the environment variable bound to a viper key.
+func envName(prefix string, key string) string {
+	env := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if prefix != "" {
+		env = strings.ToUpper(prefix) + "_" + env
+	}
+	return env
+}
+
+// validate checks the required and validate tags of config.
+// It returns a ValidationError listing all offending keys, or nil.
+func validate(prefix string, config interface{}) error {
+	var errs ValidationError
+
+	walkFields(config, func(f field) {
+		if f.Nil {
+			// the parent struct pointer is not set
+			return
+		}
+		reason := checkField(f)
+		if reason != "" {
+			errs = append(errs, FieldError{Key: f.Key, Env: envName(prefix, f.Key), Reason: reason})
+		}
+	})
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkField returns why the field value is not valid, or an empty string.
+func checkField(f field) string {
+	if isEmpty(f.Value) {
+		if required, _ := strconv.ParseBool(f.Field.Tag.Get("required")); required {
+			return "is required"
+		}
+		// empty optional strings, slices and maps are not validated
+		switch f.Value.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Ptr:
+			return ""
+		}
+	}
+
+	rules := f.Field.Tag.Get("validate")
+	if rules == "" {
+		return ""
+	}
+
+	v := f.Value
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		var reason string
+		switch name {
+		case "oneof":
+			reason = checkOneOf(v, strings.Fields(arg))
+		case "min":
+			reason = checkBound(v, arg, false)
+		case "max":
+			reason = checkBound(v, arg, true)
+		case "url":
+			reason = checkURL(v)
+		default:
+			reason = fmt.Sprintf("has unknown validation rule %q", name)
+		}
+		if reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// isEmpty reports whether v is a zero value, or an empty slice or map.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func checkOneOf(v reflect.Value, opts []string) string {
+	if v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			if reason := checkOneOf(v.Index(i), opts); reason != "" {
+				return reason
+			}
+		}
+		return ""
+	}
+
+	s := fmt.Sprint(v.Interface())
+	for _, o := range opts {
+		if strings.EqualFold(s, o) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("must be one of [%s], got %q", strings.Join(opts, " "), s)
+}
+
+// checkBound compares numbers by value and strings, slices and maps by length.
+func checkBound(v reflect.Value, arg string, isMax bool) string {
+	var n float64
+	var what string
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(arg)
+			if err != nil {
+				return fmt.Sprintf("has invalid bound %q: %v", arg, err)
+			}
+			if isMax && v.Int() > int64(d) {
+				return fmt.Sprintf("must be at most %s", d)
+			}
+			if !isMax && v.Int() < int64(d) {
+				return fmt.Sprintf("must be at least %s", d)
+			}
+			return ""
+		}
+		n, what = float64(v.Int()), "value"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, what = float64(v.Uint()), "value"
+	case reflect.Float32, reflect.Float64:
+		n, what = v.Float(), "value"
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		n, what = float64(v.Len()), "length"
+	default:
+		return fmt.Sprintf("cannot apply bound to %s", v.Kind())
+	}
+
+	bound, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return fmt.Sprintf("has invalid bound %q: %v", arg, err)
+	}
+	if isMax && n > bound {
+		return fmt.Sprintf("%s must be at most %s", what, arg)
+	}
+	if !isMax && n < bound {
+		return fmt.Sprintf("%s must be at least %s", what, arg)
+	}
+	return ""
+}
+
+func checkURL(v reflect.Value) string {
+	if v.Kind() != reflect.String {
+		return fmt.Sprintf("cannot validate %s as url", v.Kind())
+	}
+	u, err := url.Parse(v.String())
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Sprintf("must be a valid url, got %q", v.String())
+	}
+	return ""
+}
@@ -0,0 +1,218 @@
+package confighelper
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Change notifies subscribers of a reloaded configuration.
+type Change[T any] struct {
+	Old  T        // Old is the configuration before the change.
+	New  T        // New is the reloaded and validated configuration.
+	Keys []string // Keys lists the configuration keys whose value changed.
+}
+
+// Has reports whether key is one of the changed keys.
+func (c Change[T]) Has(key string) bool {
+	for _, k := range c.Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Watcher reloads a configuration when its config file changes and
+// publishes the changes to its subscribers.
+type Watcher[T any] struct {
+	loader *Loader
+	fresh  func() T
+
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+
+	mu          sync.Mutex
+	current     T
+	subscribers map[int]func(Change[T])
+	onError     func(error)
+	nextID      int
+	closed      bool
+}
+
+// Watch watches the config file of l, which must have loaded current.
+// On every change, the configuration is loaded into a fresh T, as returned by fresh,
+// and validated. Valid configurations that differ from the current one are published
+// to the subscribers; invalid ones are reported to the error handler and dropped.
+func Watch[T any](l *Loader, current T, fresh func() T) (*Watcher[T], error) {
+	l.mu.Lock()
+	file := l.v.ConfigFileUsed()
+	l.mu.Unlock()
+
+	if file == "" {
+		return nil, errors.New("no config file to watch")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create file watcher: %v", err)
+	}
+
+	// the directory is watched to pick up renames and atomic saves of the file
+	file = filepath.Clean(file)
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("cannot watch config file: %v", err)
+	}
+
+	w := &Watcher[T]{
+		loader:      l,
+		fresh:       fresh,
+		watcher:     watcher,
+		done:        make(chan struct{}),
+		current:     current,
+		subscribers: map[int]func(Change[T]){},
+	}
+	go w.watch(file)
+
+	return w, nil
+}
+
+// watch reloads the configuration on the events of file until the watcher is closed.
+func (w *Watcher[T]) watch(file string) {
+	defer close(w.done)
+
+	// the real path changes when i.e. a kubernetes ConfigMap is replaced
+	realFile, _ := filepath.EvalSymlinks(file)
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			currentFile, _ := filepath.EvalSymlinks(file)
+			if (filepath.Clean(event.Name) == file && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create))) ||
+				(currentFile != "" && currentFile != realFile) {
+				realFile = currentFile
+				w.reload()
+			}
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.mu.Lock()
+			onError := w.onError
+			w.mu.Unlock()
+			if onError != nil {
+				onError(fmt.Errorf("cannot watch config file: %v", err))
+			}
+		}
+	}
+}
+
+// Subscribe calls fn on every change of the configuration.
+// The returned function removes the subscription.
+func (w *Watcher[T]) Subscribe(fn func(Change[T])) (unsubscribe func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.subscribers[id] = fn
+
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subscribers, id)
+	}
+}
+
+// OnError sets the handler for configurations that fail to load or validate.
+func (w *Watcher[T]) OnError(fn func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.onError = fn
+}
+
+// Current returns the last valid configuration.
+func (w *Watcher[T]) Current() T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.current
+}
+
+// Close stops watching the config file and publishing changes, waiting for a running
+// reload. It must not be called by subscribers.
+func (w *Watcher[T]) Close() {
+	w.mu.Lock()
+	closed := w.closed
+	w.closed = true
+	w.mu.Unlock()
+
+	if !closed {
+		w.watcher.Close()
+		<-w.done
+	}
+}
+
+func (w *Watcher[T]) reload() {
+	next := w.fresh()
+	err := w.loader.Load(&next)
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	if err != nil {
+		onError := w.onError
+		w.mu.Unlock()
+		if onError != nil {
+			onError(err)
+		}
+		return
+	}
+
+	keys := changedKeys(w.current, next)
+	if len(keys) == 0 {
+		w.mu.Unlock()
+		return
+	}
+
+	c := Change[T]{Old: w.current, New: next, Keys: keys}
+	w.current = next
+
+	subscribers := make([]func(Change[T]), 0, len(w.subscribers))
+	for _, fn := range w.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	w.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(c)
+	}
+}
+
+// changedKeys lists the keys with different values in a and b.
+func changedKeys(a interface{}, b interface{}) []string {
+	values := map[string]reflect.Value{}
+	walkFields(a, func(f field) {
+		values[f.Key] = f.Value
+	})
+
+	var keys []string
+	walkFields(b, func(f field) {
+		if old, ok := values[f.Key]; !ok || !reflect.DeepEqual(old.Interface(), f.Value.Interface()) {
+			keys = append(keys, f.Key)
+		}
+	})
+	return keys
+}
@@ -19,11 +19,13 @@ import (
 
 // Client defines graphql host parameters and client.
 type Client struct {
-	Host        string `mapstructure:"GRPC_HOST"`
+	Host        string `mapstructure:"GRPC_HOST" required:"true"`
 	AuthEnabled bool   `mapstructure:"AUTH_ENABLED"`
-	AuthSecret  string `mapstructure:"SECRET"`
-	HealthURL   string `mapstructure:"HEALTH_URL"`
+	AuthSecret  string `mapstructure:"SECRET" secret:"true"`
+	HealthURL   string `mapstructure:"HEALTH_URL" validate:"url"`
 	*dgo.Dgraph
+
+	conn *grpc.ClientConn
 }
 
 type authorizationCredentials struct {
@@ -40,24 +42,18 @@ func (a *authorizationCredentials) RequireTransportSecurity() bool {
 
 // Load loads the graphql host parameters from environment
 func Load(ctx context.Context, cFile string, prefix string) (Client, error) {
+	return load(ctx, confighelper.WithFile(cFile), confighelper.WithPrefix(prefix))
+}
+
+func load(ctx context.Context, opts ...confighelper.Option) (Client, error) {
 	c := Client{}
 
-	if err := confighelper.ReadConfig(cFile, prefix, &c); err != nil {
+	l := confighelper.New(opts...)
+	if err := l.LoadContext(ctx, &c); err != nil {
 		return c, err
 	}
 
-	log.Debugf("# Dgraph config... ")
-	log.Debugf("Dgraph GRPC HOST: %v", c.Host)
-	log.Debugf("Dgraph auth enabled: %v", c.AuthEnabled)
-
-	if c.AuthSecret != "" {
-		log.Debugf("Dgraph secret: %v", "***")
-	} else {
-		log.Debugf("Dgraph secret: %v", "<empty>")
-	}
-
-	log.Debugf("Dgraph health URL: %v", c.HealthURL)
-	log.Debugln("...")
+	log.WithFields(l.Describe(&c).Fields()).Debug("dgraph config")
 
 	return c, nil
 }
@@ -92,21 +88,32 @@ func (c *Client) Initialize(ctx context.Context) (err error) {
 
 	dgraphClient := api.NewDgraphClient(conn)
 	c.Dgraph = dgo.NewDgraphClient(dgraphClient)
+	c.conn = conn
 
 	return
 }
 
+// Close closes the grpc connection of the client.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
 // Configuration used for initialization
 type Configuration struct {
-	Path      string // Path to config file.
-	Prefix    string // Prefix to environment variables.
-	RetryDial int    // Retry grpc dial in case server requires a cold start
+	Path      string                // Path to config file.
+	Prefix    string                // Prefix to environment variables.
+	RetryDial int                   // Retry grpc dial in case server requires a cold start
+	Secrets   confighelper.Resolver // Resolves secret:// references, i.e. for the auth secret.
+	Sources   []confighelper.Option // Layered sources, i.e. confighelper.WithDotEnv, applied after Path and Prefix.
 }
 
 // Init client
 func Init(ctx context.Context, conf Configuration) (Client, error) {
 
-	client, err := Load(ctx, conf.Path, conf.Prefix)
+	client, err := load(ctx, confighelper.Options(conf.Path, conf.Prefix, conf.Secrets, conf.Sources...)...)
 	if err != nil {
 		return client, fmt.Errorf("Load: %v", err)
 	}
@@ -127,14 +134,18 @@ func Init(ctx context.Context, conf Configuration) (Client, error) {
 }
 
 // Healthcheck checks if the dgraph server is online using the health endpoint.
-func (c *Client) Healthcheck() error {
-	req, _ := http.NewRequest("OPTIONS", c.HealthURL, nil)
+func (c *Client) Healthcheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "OPTIONS", c.HealthURL, nil)
+	if err != nil {
+		return err
+	}
 
 	resp, err := http.DefaultClient.Do(req)
 
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		err := fmt.Errorf("got error code %d", resp.StatusCode)
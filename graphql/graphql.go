@@ -25,25 +25,27 @@ type Node interface {
 
 // Client defines graphql host parameters.
 type Client struct {
-	Host        string `mapstructure:"HOST"`
-	HealthURL   string `mapstructure:"HEALTH_URL"`
+	Host        string `mapstructure:"HOST" required:"true" validate:"url"`
+	HealthURL   string `mapstructure:"HEALTH_URL" validate:"url"`
 	AuthEnabled bool   `mapstructure:"AUTH_ENABLED"`
-	AuthSecret  string `mapstructure:"SECRET"`
-	Proxy       string `mapstructure:"PROXY"`
+	AuthSecret  string `mapstructure:"SECRET" secret:"true"`
+	Proxy       string `mapstructure:"PROXY" validate:"url"`
 	*APIClient
 }
 
 // Configuration used for initialization
 type Configuration struct {
-	Path          string // Path to config file.
-	Prefix        string // Prefix to environment variables.
+	Path          string                // Path to config file.
+	Prefix        string                // Prefix to environment variables.
+	Secrets       confighelper.Resolver // Resolves secret:// references, i.e. for the auth secret.
+	Sources       []confighelper.Option // Layered sources, i.e. confighelper.WithDotEnv, applied after Path and Prefix.
 	RequestOption RequestOption
 	Log           func(string)
 }
 
 // Init client
 func Init(ctx context.Context, conf Configuration, opts ...ClientOption) (Client, error) {
-	c, err := LoadConfig(ctx, conf.Path, conf.Prefix, opts...)
+	c, err := loadConfig(ctx, confighelper.Options(conf.Path, conf.Prefix, conf.Secrets, conf.Sources...), opts...)
 	if err != nil {
 		return c, fmt.Errorf("Load: %v", err)
 	}
@@ -58,29 +60,21 @@ func Init(ctx context.Context, conf Configuration, opts ...ClientOption) (Client
 
 // LoadConfig loads the graphql host parameters from environment
 func LoadConfig(ctx context.Context, cFile string, prefix string, opts ...ClientOption) (Client, error) {
+	return loadConfig(ctx, confighelper.Options(cFile, prefix, nil), opts...)
+}
+
+func loadConfig(ctx context.Context, loadOpts []confighelper.Option, opts ...ClientOption) (Client, error) {
 	c := Client{}
 
-	if err := confighelper.ReadConfig(cFile, prefix, &c); err != nil {
+	l := confighelper.New(loadOpts...)
+	if err := l.LoadContext(ctx, &c); err != nil {
 		return c, err
 	}
 
-	log.Debugf("# GraphQL config... ")
-	log.Debugf("GraphQL Host: %v", c.Host)
-	log.Debugf("GraphQL auth enabled: %v", c.AuthEnabled)
-
-	if c.AuthSecret != "" {
-		log.Debugf("GraphQL secret: %v", "***")
-	} else {
-		log.Debugf("GraphQL secret: %v", "<empty>")
-	}
-
-	log.Debugf("GraphQL health URL: %v", c.HealthURL)
+	log.WithFields(l.Describe(&c).Fields()).Debug("graphql config")
 
 	// setup client with auth proxy
 	if proxy, err := url.Parse(c.Proxy); err == nil && proxy.String() != "" {
-
-		log.Debugf("GraphQL proxy: %s", proxy.String())
-
 		// use custom client with proxy
 		host, _ := url.Parse(c.Host)
 		host.Host = proxy.Host
@@ -89,8 +83,6 @@ func LoadConfig(ctx context.Context, cFile string, prefix string, opts ...Client
 		c.APIClient = api.NewClient(c.Host, opts...)
 	}
 
-	log.Debugln("...")
-
 	return c, nil
 }
 
@@ -388,14 +380,18 @@ func (c *Client) CustomNodeMutationWithoutInput(ctx context.Context, customFn st
 }
 
 // Healthcheck checks if the graphql server is online using the health endpoint.
-func (c *Client) Healthcheck() error {
-	req, _ := http.NewRequest("OPTIONS", c.HealthURL, nil)
+func (c *Client) Healthcheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "OPTIONS", c.HealthURL, nil)
+	if err != nil {
+		return err
+	}
 
 	resp, err := http.DefaultClient.Do(req)
 
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		err := fmt.Errorf("got error code %d", resp.StatusCode)
@@ -0,0 +1,3 @@
+module github.com/onmi-bv/commons/health
+
+go 1.19
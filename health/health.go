@@ -0,0 +1,226 @@
+// Package health serves the health of a service and its dependencies over http.
+//
+// The clients of the commons packages implement Checker, so they can be registered directly:
+//
+//	h := health.NewHandler(health.WithTimeout(time.Second), health.WithCacheTTL(5*time.Second))
+//	h.AddReadinessCheck("redis", &redisClient)
+//	mux.Handle("/healthz", h)
+//	mux.Handle("/readyz", h)
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Checker checks the health of a dependency.
+type Checker interface {
+	Healthcheck(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Healthcheck calls f.
+func (f CheckerFunc) Healthcheck(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Status of a check.
+type Status string
+
+// Statuses.
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of a check.
+type Result struct {
+	Status    Status    `json:"status"`
+	Latency   string    `json:"latency"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Report is served as JSON by the handler.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name     string
+	checker  Checker
+	liveness bool
+}
+
+// Handler serves /healthz with the liveness checks and /readyz with
+// all checks. It responds 200 if all checks pass, or else 503.
+type Handler struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.Mutex
+	checks []check
+	cache  map[string]Result
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithTimeout sets the timeout of each check. It defaults to 2 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Handler) {
+		h.timeout = d
+	}
+}
+
+// WithCacheTTL sets how long results are reused before checking again.
+// It defaults to 0, checking on every request.
+func WithCacheTTL(d time.Duration) Option {
+	return func(h *Handler) {
+		h.cacheTTL = d
+	}
+}
+
+// NewHandler creates a handler without checks.
+func NewHandler(opts ...Option) *Handler {
+	h := &Handler{
+		timeout: 2 * time.Second,
+		cache:   map[string]Result{},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// AddLivenessCheck adds a check to /healthz and /readyz. Only add dependencies
+// without which the service cannot recover, as failing liveness checks restart it.
+func (h *Handler) AddLivenessCheck(name string, c Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks = append(h.checks, check{name: name, checker: c, liveness: true})
+}
+
+// AddReadinessCheck adds a check to /readyz.
+func (h *Handler) AddReadinessCheck(name string, c Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks = append(h.checks, check{name: name, checker: c})
+}
+
+// ServeHTTP serves the report for paths ending with /healthz or /readyz.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var report Report
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/healthz"):
+		report = h.Check(r.Context(), true)
+	case strings.HasSuffix(r.URL.Path, "/readyz"):
+		report = h.Check(r.Context(), false)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// Check runs the liveness checks, or all checks, concurrently.
+// Cached results younger than the cache TTL are reused. Results of checks
+// canceled by ctx are not cached.
+func (h *Handler) Check(ctx context.Context, liveness bool) Report {
+	h.mu.Lock()
+	checks := make([]check, 0, len(h.checks))
+	for _, c := range h.checks {
+		if !liveness || c.liveness {
+			checks = append(checks, c)
+		}
+	}
+	h.mu.Unlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = h.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// run runs a single check, or returns its cached result.
+func (h *Handler) run(ctx context.Context, c check) Result {
+	h.mu.Lock()
+	cached, ok := h.cache[c.name]
+	h.mu.Unlock()
+
+	if ok && time.Since(cached.CheckedAt) < h.cacheTTL {
+		return cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	// checkers that ignore the context still time out
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.checker.Healthcheck(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := Result{
+		Status:    StatusOK,
+		Latency:   time.Since(start).String(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	// a check aborted by the caller says nothing about the dependency
+	if errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled) {
+		return res
+	}
+
+	h.mu.Lock()
+	h.cache[c.name] = res
+	h.mu.Unlock()
+
+	return res
+}
@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	h := NewHandler()
+	h.AddLivenessCheck("live", CheckerFunc(func(ctx context.Context) error { return nil }))
+	h.AddReadinessCheck("db", CheckerFunc(func(ctx context.Context) error { return errors.New("down") }))
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantChecks map[string]Status
+	}{
+		{"/healthz", http.StatusOK, map[string]Status{"live": StatusOK}},
+		{"/readyz", http.StatusServiceUnavailable, map[string]Status{"live": StatusOK, "db": StatusFail}},
+		{"/other", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantChecks == nil {
+				return
+			}
+
+			var report Report
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatalf("cannot decode report: %v", err)
+			}
+			if len(report.Checks) != len(tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", report.Checks, tt.wantChecks)
+			}
+			for name, want := range tt.wantChecks {
+				if got := report.Checks[name].Status; got != want {
+					t.Errorf("check %s = %s, want %s", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerTimeout(t *testing.T) {
+	h := NewHandler(WithTimeout(10 * time.Millisecond))
+	block := make(chan struct{})
+	defer close(block)
+	h.AddReadinessCheck("stuck", CheckerFunc(func(ctx context.Context) error {
+		<-block // ignores the context
+		return nil
+	}))
+
+	report := h.Check(context.Background(), false)
+	if r := report.Checks["stuck"]; r.Status != StatusFail || r.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("stuck check = %+v, want deadline exceeded", r)
+	}
+}
+
+func TestHandlerCache(t *testing.T) {
+	var calls int32
+	h := NewHandler(WithCacheTTL(time.Minute))
+	h.AddReadinessCheck("db", CheckerFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}))
+
+	for i := 0; i < 3; i++ {
+		h.Check(context.Background(), false)
+	}
+	if calls != 1 {
+		t.Errorf("checker called %d times, want 1", calls)
+	}
+}
+
+func TestHandlerCacheCanceled(t *testing.T) {
+	h := NewHandler(WithCacheTTL(time.Minute))
+	h.AddReadinessCheck("db", CheckerFunc(func(ctx context.Context) error {
+		return ctx.Err()
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := h.Check(ctx, false); report.Status != StatusFail {
+		t.Errorf("canceled check status = %s, want %s", report.Status, StatusFail)
+	}
+
+	// a cached result of the canceled check would still fail
+	if report := h.Check(context.Background(), false); report.Status != StatusOK {
+		t.Errorf("status = %s after a canceled check, want %s", report.Status, StatusOK)
+	}
+}
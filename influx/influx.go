@@ -12,12 +12,12 @@ import (
 
 // Config defines connection configurations
 type Config struct {
-	URL         string `mapstructure:"URL"`
+	URL         string `mapstructure:"URL" required:"true" validate:"url"`
 	Database    string `mapstructure:"DATABASE"`
 	Measurement string `mapstructure:"MEASUREMENT"`
 	AuthEnabled bool   `mapstructure:"AUTH_ENABLED"`
 	Username    string `mapstructure:"USERNAME"`
-	Password    string `mapstructure:"PASSWORD"`
+	Password    string `mapstructure:"PASSWORD" secret:"true"`
 	Cli         client.Client
 }
 
@@ -61,33 +61,60 @@ func LoadAndInitialize(ctx context.Context, cFile string, prefix string) (cli cl
 	return
 }
 
-// Load loads redis configuration from file and environment.
-func Load(ctx context.Context, cFile string, prefix string) (c Config, err error) {
+// Configuration used for initialization
+type Configuration struct {
+	Path    string                // Path to config file.
+	Prefix  string                // Prefix to environment variables.
+	Secrets confighelper.Resolver // Resolves secret:// references, i.e. for the password.
+	Sources []confighelper.Option // Layered sources, i.e. confighelper.WithDotEnv, applied after Path and Prefix.
+}
+
+// Init loads configuration from file or environment and connects.
+func Init(ctx context.Context, conf Configuration) (Config, error) {
+	c, err := load(ctx, confighelper.Options(conf.Path, conf.Prefix, conf.Secrets, conf.Sources...)...)
+	if err != nil {
+		return c, err
+	}
+	_, err = c.Initialize(ctx)
+	return c, err
+}
+
+// Load loads influx configuration from file and environment.
+func Load(ctx context.Context, cFile string, prefix string) (Config, error) {
+	return load(ctx, confighelper.WithFile(cFile), confighelper.WithPrefix(prefix))
+}
+
+func load(ctx context.Context, opts ...confighelper.Option) (c Config, err error) {
 	c = NewConfig()
 
-	err = confighelper.ReadConfig(cFile, prefix, &c)
+	l := confighelper.New(opts...)
+	err = l.LoadContext(ctx, &c)
 	if err != nil {
 		return
 	}
 
-	log.Debugf("# Influx config... ")
-	log.Debugf("Influx URL: %v", c.URL)
-	log.Debugf("Influx database: %v", c.Database)
-	log.Debugf("Influx measurement: %v", c.Measurement)
-	log.Debugf("Influx auth enabled: %v", c.AuthEnabled)
-	log.Debugf("Influx username: %v", c.Username)
-	if c.Password != "" {
-		log.Debugf("Influx password: %v", "***")
-	} else {
-		log.Debugf("Influx password: %v", "<empty>")
-	}
-	log.Debugln("...")
+	log.WithFields(l.Describe(&c).Fields()).Debug("influx config")
 
 	return
 }
 
-// Healthcheck checks if the dgraph server is online using the health endpoint.
-func (c *Config) Healthcheck() error {
-	_, _, err := c.Cli.Ping(1 * time.Second)
+// minPingTimeout is the shortest timeout of the healthcheck ping.
+const minPingTimeout = time.Millisecond
+
+// Healthcheck checks if the influx server is online using ping.
+// The ping times out at the deadline of ctx, or else after a second.
+func (c *Config) Healthcheck(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	timeout := 1 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	// a zero timeout disables the ping timeout of the client
+	if timeout < minPingTimeout {
+		timeout = minPingTimeout
+	}
+	_, _, err := c.Cli.Ping(timeout)
 	return err
 }
@@ -0,0 +1,389 @@
+// Package alerting sends log entries as alerts to notifiers, i.e. slack, a JSON
+// webhook, a Teams card or an email. Each notifier has its own rule selecting
+// the entries and fields to send, and templates for the title and text.
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	logrus "github.com/sirupsen/logrus"
+)
+
+// Alert is a log entry sent to a notifier.
+type Alert struct {
+	Service string                 `json:"service,omitempty"`
+	Version string                 `json:"version,omitempty"`
+	Level   string                 `json:"level"`
+	Time    time.Time              `json:"time"`
+	Message string                 `json:"message"`
+	Error   string                 `json:"error,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+	Caller  *Caller                `json:"caller,omitempty"`
+
+	// Err is the error field of the entry, for notifiers rendering its chain.
+	Err error `json:"-"`
+
+	// Title and Text are rendered from the templates of the rule.
+	Title string `json:"title"`
+	Text  string `json:"text,omitempty"`
+}
+
+// Caller is the source location of a log entry.
+type Caller struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function"`
+}
+
+// callerSkip lists the packages skipped for locating the caller of an entry.
+var callerSkip = []string{
+	"github.com/sirupsen/logrus",
+	"github.com/onmi-bv/commons/internal/alerting",
+	"github.com/onmi-bv/commons/logger",
+}
+
+// caller returns the location of e, reported by logrus or located in the call stack.
+func caller(e *logrus.Entry) *Caller {
+	if e.HasCaller() {
+		return &Caller{File: e.Caller.File, Line: e.Caller.Line, Function: e.Caller.Function}
+	}
+
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		f, more := frames.Next()
+		if !skipCaller(f.Function) {
+			return &Caller{File: f.File, Line: f.Line, Function: f.Function}
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// skipCaller reports whether fn is a function of the callerSkip packages.
+func skipCaller(fn string) bool {
+	slash := strings.LastIndex(fn, "/")
+	if dot := strings.Index(fn[slash+1:], "."); dot >= 0 {
+		fn = fn[:slash+1+dot]
+	}
+	for _, pkg := range callerSkip {
+		if fn == pkg {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldNames returns the names of the fields, sorted.
+func (a *Alert) FieldNames() []string {
+	names := make([]string, 0, len(a.Fields))
+	for k := range a.Fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Notifier sends alerts. Notifiers with a Close(ctx) error method are closed
+// with the hook, once the queued alerts are sent.
+type Notifier interface {
+	Notify(ctx context.Context, a *Alert) error
+}
+
+// Rule selects the entries sent to a notifier and renders their title and text.
+type Rule struct {
+	// Enabled enables the notifier.
+	Enabled bool `mapstructure:"ENABLED" default:"false"`
+
+	// Level sets the least severe level sent.
+	Level string `mapstructure:"LEVEL" default:"error" validate:"oneof=trace debug info warn warning error fatal panic"`
+
+	// Fields lists the fields sent, all if empty.
+	Fields []string `mapstructure:"FIELDS"`
+
+	// ExcludeFields lists the fields not sent.
+	ExcludeFields []string `mapstructure:"EXCLUDE_FIELDS"`
+
+	// Match selects the entries with these field values, i.e. component=billing.
+	Match map[string]string `mapstructure:"MATCH"`
+
+	// Title and Text are templates of the alert, executed with the Alert.
+	Title string `mapstructure:"TITLE" default:"[{{.Service}}] {{.Level}}: {{.Message}}"`
+	Text  string `mapstructure:"TEXT" default:"{{.Error}}"`
+}
+
+// route is a notifier with its compiled rule.
+type route struct {
+	name     string
+	notifier Notifier
+	level    logrus.Level
+	fields   map[string]bool
+	exclude  map[string]bool
+	match    map[string]string
+	title    *template.Template
+	text     *template.Template
+}
+
+func newRoute(name string, r Rule, n Notifier) (*route, error) {
+	level, err := logrus.ParseLevel(r.Level)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s level: %v", name, err)
+	}
+	title, err := template.New("title").Parse(r.Title)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s title template: %v", name, err)
+	}
+	text, err := template.New("text").Parse(r.Text)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s text template: %v", name, err)
+	}
+
+	rt := &route{
+		name:     name,
+		notifier: n,
+		level:    level,
+		match:    r.Match,
+		title:    title,
+		text:     text,
+	}
+	if len(r.Fields) > 0 {
+		rt.fields = set(r.Fields)
+	}
+	rt.exclude = set(r.ExcludeFields)
+	return rt, nil
+}
+
+// accepts reports whether e is sent to the notifier.
+func (rt *route) accepts(e *logrus.Entry) bool {
+	if e.Level > rt.level {
+		return false
+	}
+	for k, v := range rt.match {
+		if f, ok := e.Data[k]; !ok || fmt.Sprint(f) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// alert builds the alert of e with the fields and templates of the rule.
+func (rt *route) alert(base Alert, e *logrus.Entry) (*Alert, error) {
+	a := base
+	a.Fields = map[string]interface{}{}
+	for k, v := range e.Data {
+		if k == logrus.ErrorKey || rt.exclude[k] || (rt.fields != nil && !rt.fields[k]) {
+			continue
+		}
+		a.Fields[k] = v
+	}
+
+	var title, text bytes.Buffer
+	if err := rt.title.Execute(&title, &a); err != nil {
+		return nil, fmt.Errorf("cannot render %s title: %v", rt.name, err)
+	}
+	if err := rt.text.Execute(&text, &a); err != nil {
+		return nil, fmt.Errorf("cannot render %s text: %v", rt.name, err)
+	}
+	a.Title, a.Text = title.String(), text.String()
+	return &a, nil
+}
+
+// delivery is a queued alert of a notifier.
+type delivery struct {
+	route    *route
+	notifier Notifier
+	alert    *Alert
+}
+
+// Hook is a logrus hook sending the entries to the notifiers of their rules.
+// Alerts are sent from a background worker; they are dropped if the queue is full.
+type Hook struct {
+	service string
+	version string
+	timeout time.Duration
+	onError func(error)
+	routes  []*route
+
+	queue chan delivery
+	done  chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewHook creates a hook for the service, with the queue size and the send timeout
+// of the alerts. Add notifiers with Add.
+func NewHook(service string, version string, queueSize int, timeout time.Duration, onError func(error)) *Hook {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if onError == nil {
+		onError = func(err error) {
+			fmt.Fprintf(os.Stderr, "alerting: %v\n", err)
+		}
+	}
+
+	h := &Hook{
+		service: service,
+		version: version,
+		timeout: timeout,
+		onError: onError,
+		queue:   make(chan delivery, queueSize),
+		done:    make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// Add sends the entries selected by r to n. The name identifies n in errors.
+func (h *Hook) Add(name string, r Rule, n Notifier) error {
+	rt, err := newRoute(name, r, n)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.routes = append(h.routes, rt)
+	return nil
+}
+
+// Replace replaces the notifier added as name by n, for the alerts fired later.
+// It reports whether the notifier was found.
+func (h *Hook) Replace(name string, n Notifier) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, rt := range h.routes {
+		if rt.name == name {
+			rt.notifier = n
+			return true
+		}
+	}
+	return false
+}
+
+// Levels implements logrus.Hook, returning the levels of the least severe rule.
+func (h *Hook) Levels() []logrus.Level {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var levels []logrus.Level
+	for _, l := range logrus.AllLevels {
+		for _, rt := range h.routes {
+			if l <= rt.level {
+				levels = append(levels, l)
+				break
+			}
+		}
+	}
+	return levels
+}
+
+// Fire implements logrus.Hook, queueing the alerts of e.
+func (h *Hook) Fire(e *logrus.Entry) error {
+	base := Alert{
+		Service: h.service,
+		Version: h.version,
+		Level:   e.Level.String(),
+		Time:    e.Time,
+		Message: e.Message,
+	}
+	if err, ok := e.Data[logrus.ErrorKey]; ok {
+		base.Error = fmt.Sprint(err)
+		base.Err, _ = err.(error)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil
+	}
+	located := false
+	for _, rt := range h.routes {
+		if !rt.accepts(e) {
+			continue
+		}
+		if !located {
+			base.Caller, located = caller(e), true
+		}
+		a, err := rt.alert(base, e)
+		if err != nil {
+			return err
+		}
+		select {
+		case h.queue <- delivery{rt, rt.notifier, a}:
+		default:
+			h.onError(fmt.Errorf("cannot send %s alert: the queue is full", rt.name))
+		}
+	}
+	return nil
+}
+
+// Close stops accepting alerts and sends the queued ones, waiting until they
+// are sent or ctx is done. Then the notifiers with a Close method are closed.
+func (h *Hook) Close(ctx context.Context) error {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.queue)
+	}
+	routes := make([]route, len(h.routes))
+	for i, rt := range h.routes {
+		routes[i] = *rt
+	}
+	h.mu.Unlock()
+
+	select {
+	case <-h.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var err error
+	for _, rt := range routes {
+		c, ok := rt.notifier.(interface{ Close(context.Context) error })
+		if !ok {
+			continue
+		}
+		if cerr := c.Close(ctx); cerr != nil && err == nil {
+			err = fmt.Errorf("cannot close %s: %v", rt.name, cerr)
+		}
+	}
+	return err
+}
+
+func (h *Hook) run() {
+	defer close(h.done)
+
+	for d := range h.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+		if err := d.notifier.Notify(ctx, d.alert); err != nil {
+			h.onError(fmt.Errorf("cannot send %s alert: %v", d.route.name, err))
+		}
+		cancel()
+	}
+}
+
+func set(keys []string) map[string]bool {
+	m := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		m[k] = true
+	}
+	return m
+}
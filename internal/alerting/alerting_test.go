@@ -0,0 +1,362 @@
+package alerting
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	slack "github.com/onmi-bv/commons/internal/slack"
+	logrus "github.com/sirupsen/logrus"
+)
+
+// recorder is a notifier recording the alerts.
+type recorder struct {
+	mu     sync.Mutex
+	alerts []*Alert
+}
+
+func (r *recorder) Notify(ctx context.Context, a *Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.alerts = append(r.alerts, a)
+	return nil
+}
+
+func rule(level string) Rule {
+	return Rule{
+		Enabled: true,
+		Level:   level,
+		Title:   "[{{.Service}}] {{.Level}}: {{.Message}}",
+		Text:    "{{.Error}}",
+	}
+}
+
+func newTestLogger(h *Hook) *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetLevel(logrus.TraceLevel)
+	l.AddHook(h)
+	return l
+}
+
+func TestHookRules(t *testing.T) {
+	h := NewHook("api", "1.0.0", 10, time.Second, nil)
+
+	warned := &recorder{}
+	warnings := rule("warning")
+	warnings.Fields = []string{"user", "component"}
+	warnings.ExcludeFields = []string{"user"}
+	billing := &recorder{}
+	matched := rule("info")
+	matched.Match = map[string]string{"component": "billing"}
+	matched.Title = "{{.Message}} for {{index .Fields \"user\"}}"
+
+	if err := h.Add("warnings", warnings, warned); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Add("billing", matched, billing); err != nil {
+		t.Fatal(err)
+	}
+
+	l := newTestLogger(h)
+	l.Debug("ignored")
+	l.WithError(io.EOF).WithFields(logrus.Fields{"user": "bob", "component": "api", "other": 1}).Error("cannot read")
+	l.WithFields(logrus.Fields{"user": "bob", "component": "billing"}).Info("invoice sent")
+
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(warned.alerts) != 1 {
+		t.Fatalf("warnings received %d alerts, want 1", len(warned.alerts))
+	}
+	a := warned.alerts[0]
+	if a.Title != "[api] error: cannot read" || a.Text != "EOF" {
+		t.Errorf("alert = %q %q, want rendered templates", a.Title, a.Text)
+	}
+	if len(a.Fields) != 1 || a.Fields["component"] != "api" {
+		t.Errorf("alert fields = %v, want component only", a.Fields)
+	}
+
+	if len(billing.alerts) != 1 || billing.alerts[0].Title != "invoice sent for bob" {
+		t.Errorf("billing received %+v, want the matched entry", billing.alerts)
+	}
+}
+
+func TestConfigNewHook(t *testing.T) {
+	c := Config{}
+	if c.Enabled() {
+		t.Error("Enabled() = true without notifiers")
+	}
+
+	c.Webhook.Rule = rule("error")
+	if _, err := c.NewHook("api", "1.0.0"); err == nil || !strings.Contains(err.Error(), "URL is required") {
+		t.Errorf("NewHook() error = %v, want the required webhook URL", err)
+	}
+
+	c.Webhook.URL = "http://localhost"
+	c.Webhook.Title = "{{.Message"
+	if _, err := c.NewHook("api", "1.0.0"); err == nil {
+		t.Error("NewHook() with an invalid template succeeded")
+	}
+}
+
+func TestConfigNewHookRequired(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"slack", Config{Slack: Slack{Rule: rule("error")}}},
+		{"webhook", Config{Webhook: Webhook{Rule: rule("error")}}},
+		{"teams", Config{Teams: Teams{Rule: rule("error")}}},
+		{"email", Config{Email: Email{Rule: rule("error"), To: []string{"ops@example.com"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.config.NewHook("api", "1.0.0"); err == nil {
+				t.Errorf("NewHook() enabled %s without destination", tt.name)
+			}
+		})
+	}
+}
+
+// closingRecorder is a recorder closed with the hook.
+type closingRecorder struct {
+	recorder
+	closed int
+}
+
+func (r *closingRecorder) Close(ctx context.Context) error {
+	r.closed++
+	return nil
+}
+
+func TestHookReplace(t *testing.T) {
+	h := NewHook("api", "1.0.0", 10, time.Second, nil)
+	old, next := &closingRecorder{}, &closingRecorder{}
+	if err := h.Add("slack", rule("error"), old); err != nil {
+		t.Fatal(err)
+	}
+
+	l := newTestLogger(h)
+	l.SetReportCaller(true)
+	l.Error("before")
+	if h.Replace("other", next) {
+		t.Error("Replace() found an unknown notifier")
+	}
+	if !h.Replace("slack", next) {
+		t.Fatal("Replace() did not find the notifier")
+	}
+	l.WithError(fmt.Errorf("cannot read: %w", io.EOF)).Error("after")
+
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if len(old.alerts) != 1 || len(next.alerts) != 1 {
+		t.Fatalf("notifiers received %d and %d alerts, want 1 and 1", len(old.alerts), len(next.alerts))
+	}
+	if old.closed != 0 || next.closed != 1 {
+		t.Errorf("notifiers closed %d and %d times, want the current one closed once", old.closed, next.closed)
+	}
+
+	a := next.alerts[0]
+	if !errors.Is(a.Err, io.EOF) {
+		t.Errorf("alert error = %v, want the error chain", a.Err)
+	}
+	if a.Caller == nil || !strings.HasSuffix(a.Caller.File, "alerting_test.go") {
+		t.Errorf("alert caller = %+v, want the test", a.Caller)
+	}
+}
+
+func TestSkipCaller(t *testing.T) {
+	tests := map[string]bool{
+		"github.com/sirupsen/logrus.(*Entry).Log":                          true,
+		"github.com/onmi-bv/commons/logger.(*sampler).Fire":                true,
+		"github.com/onmi-bv/commons/logger/internals/forward.(*Hook).Fire": false,
+		"main.main": false,
+		"github.com/onmi-bv/commons/cloudevents.StartReceiver.func1": false,
+	}
+	for fn, want := range tests {
+		if got := skipCaller(fn); got != want {
+			t.Errorf("skipCaller(%q) = %v, want %v", fn, got, want)
+		}
+	}
+}
+
+// server is a stand-in for the webhooks, recording the request bodies.
+func server(t *testing.T, bodies chan<- []byte, header *http.Header) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if header != nil {
+			*header = r.Header.Clone()
+		}
+		bodies <- b
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func testAlert() *Alert {
+	return &Alert{
+		Service: "api",
+		Level:   "error",
+		Time:    time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		Message: "cannot read",
+		Error:   "EOF",
+		Fields:  map[string]interface{}{"user": "bob"},
+		Title:   "[api] error: cannot read",
+		Text:    "EOF",
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	var header http.Header
+	s := server(t, bodies, &header)
+
+	w := &Webhook{URL: s.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+	if err := w.Notify(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	var a Alert
+	if err := json.Unmarshal(<-bodies, &a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Title != "[api] error: cannot read" || a.Fields["user"] != "bob" {
+		t.Errorf("posted %+v", a)
+	}
+	if header.Get("Authorization") != "Bearer token" {
+		t.Errorf("Authorization = %q, want the configured header", header.Get("Authorization"))
+	}
+}
+
+func TestTeams(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	s := server(t, bodies, nil)
+
+	if err := (&Teams{URL: s.URL}).Notify(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	var card messageCard
+	if err := json.Unmarshal(<-bodies, &card); err != nil {
+		t.Fatal(err)
+	}
+	if card.Type != "MessageCard" || card.Title != "[api] error: cannot read" || card.ThemeColor != "D70000" {
+		t.Errorf("posted %+v", card)
+	}
+	if len(card.Sections) != 1 || card.Sections[0].Facts[0] != (cardFact{"user", "bob"}) {
+		t.Errorf("posted sections %+v, want the fields as facts", card.Sections)
+	}
+}
+
+func TestSlack(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	s := server(t, bodies, nil)
+
+	if err := (&Slack{HookURL: s.URL, Channel: "#alerts"}).Notify(context.Background(), testAlert()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	var msg slack.Message
+	if err := json.Unmarshal(<-bodies, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Channel != "#alerts" || len(msg.Attachments) != 1 {
+		t.Fatalf("posted %+v", msg)
+	}
+	blocks := msg.Attachments[0].Blocks
+	if len(blocks) != 3 || blocks[0].Text.Text != "*[api] error: cannot read*" || blocks[2].Fields[0].Text != "*user*\nbob" {
+		t.Errorf("posted blocks %+v", blocks)
+	}
+}
+
+// smtpServer is a minimal SMTP stand-in, returning the received message.
+func smtpServer(t *testing.T) (addr string, received <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+		reply("220 localhost ready")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				reply("250 ok")
+				ch <- data.String()
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestEmail(t *testing.T) {
+	addr, received := smtpServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	p, _ := strconv.Atoi(port)
+	m := &Email{Host: host, Port: p, From: "alerts@example.com", To: []string{"ops@example.com"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Notify(ctx, testAlert()); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	msg := <-received
+	for _, want := range []string{"To: ops@example.com", "Subject: [api] error: cannot read", "EOF\r\n", "user: bob"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message does not contain %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestEmailNoRecipients(t *testing.T) {
+	if err := (&Email{}).Notify(context.Background(), testAlert()); err == nil {
+		t.Error("Notify() without recipients succeeded")
+	}
+}
@@ -0,0 +1,70 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Hook defaults.
+const (
+	DefaultQueueSize = 100
+	DefaultTimeout   = 10 * time.Second
+)
+
+// Config configures the notifiers of the alerting hook.
+type Config struct {
+	// QueueSize limits the queued alerts; more are dropped.
+	QueueSize int `mapstructure:"QUEUE_SIZE" default:"100" validate:"min=1"`
+
+	// Timeout limits the sending of an alert.
+	Timeout time.Duration `mapstructure:"TIMEOUT" default:"10s" validate:"min=1ms"`
+
+	Slack   Slack   `mapstructure:"SLACK"`
+	Webhook Webhook `mapstructure:"WEBHOOK"`
+	Teams   Teams   `mapstructure:"TEAMS"`
+	Email   Email   `mapstructure:"EMAIL"`
+
+	// ErrorHandler is called with the errors of sending alerts. Defaults to printing to stderr.
+	ErrorHandler func(error) `mapstructure:"-"`
+}
+
+// Enabled reports whether a notifier is enabled.
+func (c *Config) Enabled() bool {
+	return c.Slack.Enabled || c.Webhook.Enabled || c.Teams.Enabled || c.Email.Enabled
+}
+
+// NewHook creates a hook sending to the enabled notifiers, which require their
+// URL, or the HOST for email.
+func (c *Config) NewHook(service string, version string) (*Hook, error) {
+	slack, webhook, teams, email := c.Slack, c.Webhook, c.Teams, c.Email
+	notifiers := []struct {
+		name     string
+		rule     Rule
+		notifier Notifier
+		key      string // key is the required destination of the notifier
+		dest     string
+	}{
+		{"slack", slack.Rule, &slack, "HOOK_URL", slack.HookURL},
+		{"webhook", webhook.Rule, &webhook, "URL", webhook.URL},
+		{"teams", teams.Rule, &teams, "URL", teams.URL},
+		{"email", email.Rule, &email, "HOST", email.Host},
+	}
+	for _, n := range notifiers {
+		if n.rule.Enabled && n.dest == "" {
+			return nil, fmt.Errorf("cannot enable %s alerts: %s is required", n.name, n.key)
+		}
+	}
+
+	h := NewHook(service, version, c.QueueSize, c.Timeout, c.ErrorHandler)
+	for _, n := range notifiers {
+		if !n.rule.Enabled {
+			continue
+		}
+		if err := h.Add(n.name, n.rule, n.notifier); err != nil {
+			h.Close(context.Background())
+			return nil, err
+		}
+	}
+	return h, nil
+}
@@ -0,0 +1,104 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email sends alerts by SMTP. STARTTLS is used if the server supports it.
+type Email struct {
+	Rule `mapstructure:",squash"`
+
+	Host     string   `mapstructure:"HOST"` // required if enabled
+	Port     int      `mapstructure:"PORT" default:"587" validate:"min=1,max=65535"`
+	Username string   `mapstructure:"USERNAME"`
+	Password string   `mapstructure:"PASSWORD" secret:"true"`
+	From     string   `mapstructure:"FROM"`
+	To       []string `mapstructure:"TO"`
+}
+
+// Notify implements Notifier, sending the alert as a plain text email.
+func (m *Email) Notify(ctx context.Context, a *Alert) error {
+	if len(m.To) == 0 {
+		return fmt.Errorf("no email recipients")
+	}
+
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot connect to %s: %v", addr, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.Host}); err != nil {
+			return fmt.Errorf("cannot start tls: %v", err)
+		}
+	}
+	if m.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return fmt.Errorf("cannot authenticate: %v", err)
+		}
+	}
+
+	if err := c.Mail(m.From); err != nil {
+		return err
+	}
+	for _, to := range m.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(m.message(a)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message formats the alert as an email with the text and fields in the body.
+func (m *Email) message(a *Alert) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(a.Title)))
+	fmt.Fprintf(&b, "Date: %s\r\n", a.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	if a.Text != "" {
+		b.WriteString(a.Text + "\r\n\r\n")
+	}
+	for _, k := range a.FieldNames() {
+		fmt.Fprintf(&b, "%s: %v\r\n", k, a.Fields[k])
+	}
+	return b.Bytes()
+}
+
+// headerValue removes line breaks from a header value.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,12 @@
+module github.com/onmi-bv/commons/internal/alerting
+
+go 1.19
+
+require (
+	github.com/onmi-bv/commons/internal/slack v0.0.0-20230107122636-6b6bd027401a
+	github.com/sirupsen/logrus v1.9.0
+)
+
+require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+
+replace github.com/onmi-bv/commons/internal/slack => ../slack
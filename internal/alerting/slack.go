@@ -0,0 +1,64 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+
+	slack "github.com/onmi-bv/commons/internal/slack"
+	logrus "github.com/sirupsen/logrus"
+)
+
+// Slack sends alerts to a slack incoming webhook.
+type Slack struct {
+	Rule `mapstructure:",squash"`
+
+	HookURL   string `mapstructure:"HOOK_URL" validate:"url"` // required if enabled
+	Channel   string `mapstructure:"CHANNEL"`
+	Username  string `mapstructure:"USERNAME"`
+	IconEmoji string `mapstructure:"ICON_EMOJI"`
+}
+
+// Notify implements Notifier, sending the alert as an attachment of blocks.
+func (s *Slack) Notify(ctx context.Context, a *Alert) error {
+	msg := &slack.Message{
+		Username:  s.Username,
+		Channel:   s.Channel,
+		IconEmoji: s.IconEmoji,
+	}
+
+	attach := msg.NewAttachment()
+	attach.Fallback = a.Title
+	attach.Color = slackColor(a.Level)
+	attach.AddBlock(slack.NewSection("*" + a.Title + "*"))
+	if a.Text != "" {
+		attach.AddBlock(slack.NewSection(a.Text))
+	}
+
+	// slack limits a section to 10 fields
+	names := a.FieldNames()
+	for len(names) > 0 {
+		n := len(names)
+		if n > 10 {
+			n = 10
+		}
+		fields := make([]string, n)
+		for i, k := range names[:n] {
+			fields[i] = fmt.Sprintf("*%s*\n%v", k, a.Fields[k])
+		}
+		attach.AddBlock(slack.NewSection("", fields...))
+		names = names[n:]
+	}
+
+	return slack.NewClient(s.HookURL).SendMessageContext(ctx, msg)
+}
+
+func slackColor(level string) string {
+	switch level {
+	case logrus.ErrorLevel.String(), logrus.FatalLevel.String(), logrus.PanicLevel.String():
+		return "danger"
+	case logrus.WarnLevel.String():
+		return "warning"
+	default:
+		return "good"
+	}
+}
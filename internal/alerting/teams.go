@@ -0,0 +1,69 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+
+	logrus "github.com/sirupsen/logrus"
+)
+
+// Teams posts alerts as message cards to a Microsoft Teams incoming webhook.
+type Teams struct {
+	Rule `mapstructure:",squash"`
+
+	URL string `mapstructure:"URL" validate:"url"` // required if enabled
+}
+
+// messageCard is a legacy actionable message card.
+// See https://learn.microsoft.com/en-us/outlook/actionable-messages/message-card-reference.
+type messageCard struct {
+	Type       string        `json:"@type"`
+	Context    string        `json:"@context"`
+	ThemeColor string        `json:"themeColor"`
+	Summary    string        `json:"summary"`
+	Title      string        `json:"title"`
+	Text       string        `json:"text,omitempty"`
+	Sections   []cardSection `json:"sections,omitempty"`
+}
+
+type cardSection struct {
+	Facts []cardFact `json:"facts"`
+}
+
+type cardFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Notify implements Notifier, posting the alert as a message card with the fields as facts.
+func (t *Teams) Notify(ctx context.Context, a *Alert) error {
+	card := messageCard{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		ThemeColor: teamsColor(a.Level),
+		Summary:    a.Title,
+		Title:      a.Title,
+		Text:       a.Text,
+	}
+
+	if len(a.Fields) > 0 {
+		var section cardSection
+		for _, k := range a.FieldNames() {
+			section.Facts = append(section.Facts, cardFact{Name: k, Value: fmt.Sprint(a.Fields[k])})
+		}
+		card.Sections = append(card.Sections, section)
+	}
+
+	return postJSON(ctx, t.URL, nil, card)
+}
+
+func teamsColor(level string) string {
+	switch level {
+	case logrus.ErrorLevel.String(), logrus.FatalLevel.String(), logrus.PanicLevel.String():
+		return "D70000"
+	case logrus.WarnLevel.String():
+		return "FFA500"
+	default:
+		return "2EB886"
+	}
+}
@@ -0,0 +1,54 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// Webhook posts alerts as JSON to a URL.
+type Webhook struct {
+	Rule `mapstructure:",squash"`
+
+	URL string `mapstructure:"URL" validate:"url"` // required if enabled
+
+	// Headers are added to the requests, i.e. Authorization=Bearer token.
+	Headers map[string]string `mapstructure:"HEADERS" secret:"true"`
+}
+
+// Notify implements Notifier, posting the alert as JSON.
+func (w *Webhook) Notify(ctx context.Context, a *Alert) error {
+	return postJSON(ctx, w.URL, w.Headers, a)
+}
+
+// postJSON posts v as JSON to url, failing on responses other than 2xx.
+func postJSON(ctx context.Context, url string, headers map[string]string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("cannot encode alert: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response %d: %s", resp.StatusCode, b)
+	}
+	return nil
+}
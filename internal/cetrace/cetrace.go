@@ -0,0 +1,138 @@
+// Package cetrace propagates the trace context of CloudEvents with the Distributed
+// Tracing extension, the traceparent and tracestate attributes of W3C Trace Context.
+// The legacy spancontext extension, a base64 JSON span context, is still extracted.
+package cetrace
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/cloudevents/sdk-go/v2/extensions"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Extension attributes.
+const (
+	TraceParentExtension = extensions.TraceParentExtension
+	TraceStateExtension  = extensions.TraceStateExtension
+
+	// LegacyExtension is the deprecated base64 JSON span context.
+	LegacyExtension = "spancontext"
+)
+
+// Propagator injects and extracts the trace context of events.
+type Propagator struct {
+	// TextMapPropagator encodes the trace context. Defaults to W3C Trace Context.
+	TextMapPropagator propagation.TextMapPropagator
+}
+
+func (p Propagator) propagator() propagation.TextMapPropagator {
+	if p.TextMapPropagator == nil {
+		return propagation.TraceContext{}
+	}
+	return p.TextMapPropagator
+}
+
+// Inject sets the trace context of the span in ctx on e.
+func (p Propagator) Inject(ctx context.Context, e *event.Event) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+	p.propagator().Inject(ctx, carrier{e})
+}
+
+// Extract returns a copy of ctx with the remote span context of e, read from the
+// traceparent and tracestate extensions, or else from the legacy spancontext.
+func (p Propagator) Extract(ctx context.Context, e event.Event) context.Context {
+	if _, ok := e.Extensions()[TraceParentExtension]; ok {
+		return p.propagator().Extract(ctx, carrier{&e})
+	}
+	if sc, ok := legacySpanContext(e); ok {
+		return trace.ContextWithRemoteSpanContext(ctx, sc)
+	}
+	return ctx
+}
+
+// carrier adapts the extensions of an event to propagation.TextMapCarrier.
+type carrier struct {
+	e *event.Event
+}
+
+// Get implements propagation.TextMapCarrier.
+func (c carrier) Get(key string) string {
+	v, ok := c.e.Extensions()[key]
+	if !ok {
+		return ""
+	}
+	s, _ := v.(string)
+	return s
+}
+
+// Set implements propagation.TextMapCarrier. Empty values are not set.
+func (c carrier) Set(key string, value string) {
+	if value != "" {
+		c.e.SetExtension(key, value)
+	}
+}
+
+// Keys implements propagation.TextMapCarrier.
+func (c carrier) Keys() []string {
+	keys := make([]string, 0, len(c.e.Extensions()))
+	for k := range c.e.Extensions() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// legacySpanContext decodes the spancontext extension of e, the base64 JSON
+// encoding of an opentelemetry span context.
+func legacySpanContext(e event.Event) (trace.SpanContext, bool) {
+	v, ok := e.Extensions()[LegacyExtension].(string)
+	if !ok {
+		return trace.SpanContext{}, false
+	}
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+
+	var sc struct {
+		TraceID    string
+		SpanID     string
+		TraceFlags json.RawMessage
+		TraceState string
+	}
+	if err := json.Unmarshal(b, &sc); err != nil {
+		return trace.SpanContext{}, false
+	}
+
+	config := trace.SpanContextConfig{Remote: true}
+	config.TraceID, _ = trace.TraceIDFromHex(sc.TraceID)
+	config.SpanID, _ = trace.SpanIDFromHex(sc.SpanID)
+	config.TraceFlags = legacyTraceFlags(sc.TraceFlags)
+	config.TraceState, _ = trace.ParseTraceState(sc.TraceState)
+
+	spanContext := trace.NewSpanContext(config)
+	return spanContext, spanContext.IsValid()
+}
+
+// legacyTraceFlags decodes the trace flags, a hex string or a number. Missing or
+// invalid flags are sampled, as the senders of the legacy extension assumed.
+func legacyTraceFlags(raw json.RawMessage) trace.TraceFlags {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if n, err := strconv.ParseUint(s, 16, 8); err == nil {
+			return trace.TraceFlags(n)
+		}
+		return trace.FlagsSampled
+	}
+	var n uint8
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return trace.TraceFlags(n)
+	}
+	return trace.FlagsSampled
+}
@@ -0,0 +1,108 @@
+package cetrace
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	traceID, _ = trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _  = trace.SpanIDFromHex("00f067aa0ba902b7")
+)
+
+func TestInjectExtract(t *testing.T) {
+	state, _ := trace.ParseTraceState("vendor=value")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		TraceState: state,
+	}))
+
+	e := event.New()
+	Propagator{}.Inject(ctx, &e)
+
+	ext := e.Extensions()
+	if ext[TraceParentExtension] != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" || ext[TraceStateExtension] != "vendor=value" {
+		t.Fatalf("extensions = %v, want traceparent and tracestate", ext)
+	}
+
+	sc := trace.SpanContextFromContext(Propagator{}.Extract(context.Background(), e))
+	if sc.TraceID() != traceID || sc.SpanID() != spanID || !sc.IsSampled() || !sc.IsRemote() || sc.TraceState().Get("vendor") != "value" {
+		t.Errorf("extracted %+v, want the injected span context", sc)
+	}
+}
+
+func TestInjectWithoutSpan(t *testing.T) {
+	e := event.New()
+	Propagator{}.Inject(context.Background(), &e)
+	if len(e.Extensions()) != 0 {
+		t.Errorf("extensions = %v, want none", e.Extensions())
+	}
+}
+
+func TestExtractLegacy(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		valid   bool
+		sampled bool
+		state   string
+	}{
+		{
+			name:    "marshalled span context",
+			json:    `{"TraceID":"4bf92f3577b34da6a3ce929d0e0e4736","SpanID":"00f067aa0ba902b7","TraceFlags":"00","TraceState":"vendor=value","Remote":false}`,
+			valid:   true,
+			sampled: false,
+			state:   "value",
+		},
+		{
+			name:    "numeric flags",
+			json:    `{"TraceID":"4bf92f3577b34da6a3ce929d0e0e4736","SpanID":"00f067aa0ba902b7","TraceFlags":1}`,
+			valid:   true,
+			sampled: true,
+		},
+		{
+			name:    "missing flags",
+			json:    `{"TraceID":"4bf92f3577b34da6a3ce929d0e0e4736","SpanID":"00f067aa0ba902b7"}`,
+			valid:   true,
+			sampled: true,
+		},
+		{
+			name: "invalid ids",
+			json: `{"TraceID":"invalid","SpanID":"00f067aa0ba902b7"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := event.New()
+			e.SetExtension(LegacyExtension, base64.StdEncoding.EncodeToString([]byte(tt.json)))
+
+			sc := trace.SpanContextFromContext(Propagator{}.Extract(context.Background(), e))
+			if sc.IsValid() != tt.valid {
+				t.Fatalf("extracted valid = %v, want %v", sc.IsValid(), tt.valid)
+			}
+			if !tt.valid {
+				return
+			}
+			if sc.TraceID() != traceID || sc.SpanID() != spanID || sc.IsSampled() != tt.sampled || sc.TraceState().Get("vendor") != tt.state {
+				t.Errorf("extracted %+v, want the legacy span context", sc)
+			}
+		})
+	}
+}
+
+func TestExtractPrefersTraceParent(t *testing.T) {
+	e := event.New()
+	e.SetExtension(TraceParentExtension, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	e.SetExtension(LegacyExtension, base64.StdEncoding.EncodeToString([]byte(`{"TraceID":"0af7651916cd43dd8448eb211c80319c","SpanID":"b7ad6b7169203331"}`)))
+
+	sc := trace.SpanContextFromContext(Propagator{}.Extract(context.Background(), e))
+	if sc.TraceID() != traceID {
+		t.Errorf("extracted trace %s, want the traceparent", sc.TraceID())
+	}
+}
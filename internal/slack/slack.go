@@ -2,10 +2,13 @@ package slack
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 // Client ...
@@ -22,6 +25,7 @@ type Message struct {
 	Channel     string        `json:"channel"`
 	UnfurlLinks bool          `json:"unfurl_links"`
 	Attachments []*Attachment `json:"attachments"`
+	Blocks      []*Block      `json:"blocks,omitempty"`
 }
 
 // Attachment ...
@@ -32,6 +36,8 @@ type Attachment struct {
 	Pretext  string   `json:"pretext"`
 	Color    string   `json:"color"`
 	Fields   []*Field `json:"fields"`
+	Footer   string   `json:"footer,omitempty"`
+	Blocks   []*Block `json:"blocks,omitempty"`
 }
 
 // Field ...
@@ -43,8 +49,9 @@ type Field struct {
 
 // Error ...
 type Error struct {
-	Code int
-	Body string
+	Code       int
+	Body       string
+	RetryAfter time.Duration // RetryAfter is set from the Retry-After header of rate limited (429) responses.
 }
 
 func (e *Error) Error() string {
@@ -58,12 +65,22 @@ func NewClient(url string) *Client {
 
 // SendMessage ...
 func (c *Client) SendMessage(msg *Message) error {
+	return c.SendMessageContext(context.Background(), msg)
+}
+
+// SendMessageContext sends msg, canceling the request with ctx.
+func (c *Client) SendMessageContext(ctx context.Context, msg *Message) error {
 
 	body, _ := json.Marshal(msg)
 	buf := bytes.NewReader(body)
 
-	http.NewRequest("POST", c.URL, buf)
-	resp, err := http.Post(c.URL, "application/json", buf)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.URL, buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -71,7 +88,11 @@ func (c *Client) SendMessage(msg *Message) error {
 
 	if resp.StatusCode != 200 {
 		t, _ := ioutil.ReadAll(resp.Body)
-		return &Error{resp.StatusCode, string(t)}
+		e := &Error{Code: resp.StatusCode, Body: string(t)}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			e.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return e
 	}
 
 	return nil
@@ -100,3 +121,73 @@ func (a *Attachment) NewField() *Field {
 func (a *Attachment) AddField(f *Field) {
 	a.Fields = append(a.Fields, f)
 }
+
+// Block types of Block Kit.
+const (
+	HeaderBlock  = "header"
+	SectionBlock = "section"
+	ContextBlock = "context"
+	DividerBlock = "divider"
+)
+
+// Text types of Block Kit.
+const (
+	PlainText = "plain_text"
+	Markdown  = "mrkdwn"
+)
+
+// Block is a Block Kit layout block.
+// See https://api.slack.com/reference/block-kit/blocks.
+type Block struct {
+	Type     string  `json:"type"`
+	Text     *Text   `json:"text,omitempty"`
+	Fields   []*Text `json:"fields,omitempty"`
+	Elements []*Text `json:"elements,omitempty"`
+}
+
+// Text is a Block Kit text object.
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// NewHeader creates a header block with plain text.
+func NewHeader(text string) *Block {
+	return &Block{Type: HeaderBlock, Text: &Text{Type: PlainText, Text: text}}
+}
+
+// NewSection creates a section block with markdown text and fields.
+func NewSection(text string, fields ...string) *Block {
+	b := &Block{Type: SectionBlock}
+	if text != "" {
+		b.Text = &Text{Type: Markdown, Text: text}
+	}
+	for _, f := range fields {
+		b.Fields = append(b.Fields, &Text{Type: Markdown, Text: f})
+	}
+	return b
+}
+
+// NewContext creates a context block with markdown elements.
+func NewContext(elements ...string) *Block {
+	b := &Block{Type: ContextBlock}
+	for _, e := range elements {
+		b.Elements = append(b.Elements, &Text{Type: Markdown, Text: e})
+	}
+	return b
+}
+
+// NewDivider creates a divider block.
+func NewDivider() *Block {
+	return &Block{Type: DividerBlock}
+}
+
+// AddBlock ...
+func (m *Message) AddBlock(b *Block) {
+	m.Blocks = append(m.Blocks, b)
+}
+
+// AddBlock ...
+func (a *Attachment) AddBlock(b *Block) {
+	a.Blocks = append(a.Blocks, b)
+}
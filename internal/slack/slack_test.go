@@ -1,6 +1,7 @@
 package slack
 
 import (
+	"encoding/json"
 	"flag"
 	"os"
 	"os/user"
@@ -54,3 +55,21 @@ func TestSendMessageWithAttachement(t *testing.T) {
 
 	client.SendMessage(msg)
 }
+
+func TestBlocksJSON(t *testing.T) {
+	msg := &Message{}
+	attach := msg.NewAttachment()
+	attach.AddBlock(NewSection("*ERROR* failed", "*service*\napi"))
+	attach.AddBlock(NewContext("trace `abc`"))
+	attach.AddBlock(NewDivider())
+
+	b, err := json.Marshal(attach.Blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"type":"section","text":{"type":"mrkdwn","text":"*ERROR* failed"},"fields":[{"type":"mrkdwn","text":"*service*\napi"}]},` +
+		`{"type":"context","elements":[{"type":"mrkdwn","text":"trace ` + "`abc`" + `"}]},{"type":"divider"}]`
+	if string(b) != want {
+		t.Errorf("blocks = %s, want %s", b, want)
+	}
+}
@@ -0,0 +1,295 @@
+package slackrus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	slack "github.com/onmi-bv/commons/internal/slack"
+)
+
+// Queue defaults.
+const (
+	DefaultQueueSize   = 1000
+	DefaultBatchWindow = 2 * time.Second
+
+	// maxAttachments limits the attachments of a batched message.
+	maxAttachments = 50
+
+	// maxRetries limits the retries of rate limited messages.
+	maxRetries = 3
+)
+
+// errClosed is returned when flushing a closed queue.
+var errClosed = errors.New("slack queue is closed")
+
+// item is a queued log entry.
+type item struct {
+	header slack.Message // header holds the message settings, without attachments
+	attach *slack.Attachment
+	key    string // key identifies repeated entries
+}
+
+// batch collects the items of a window, deduplicated by key.
+type batch struct {
+	header  slack.Message
+	attachs []*slack.Attachment
+	repeats []int
+	index   map[string]int
+	dropped int
+}
+
+func (b *batch) add(it item) {
+	if i, ok := b.index[it.key]; ok {
+		b.repeats[i]++
+		return
+	}
+	if len(b.attachs) == 0 {
+		b.header = it.header
+		b.index = map[string]int{}
+	}
+	b.index[it.key] = len(b.attachs)
+	b.attachs = append(b.attachs, it.attach)
+	b.repeats = append(b.repeats, 1)
+}
+
+// accepts reports whether it can be added to the batch.
+func (b *batch) accepts(it item) bool {
+	if len(b.attachs) == 0 {
+		return true
+	}
+	if _, ok := b.index[it.key]; ok {
+		return true
+	}
+	return len(b.attachs) < maxAttachments && sameHeader(b.header, it.header)
+}
+
+// message builds the slack message of the batch, noting the repeat counts.
+func (b *batch) message() *slack.Message {
+	msg := b.header
+	msg.Attachments = nil
+	for i, a := range b.attachs {
+		if b.repeats[i] > 1 {
+			repeated := *a
+			note := fmt.Sprintf("repeated %d times", b.repeats[i])
+			if len(a.Blocks) > 0 {
+				repeated.Blocks = append(a.Blocks[:len(a.Blocks):len(a.Blocks)], slack.NewContext(note))
+			} else {
+				repeated.Footer = note
+			}
+			a = &repeated
+		}
+		msg.AddAttachment(a)
+	}
+	if b.dropped > 0 {
+		msg.Text = fmt.Sprintf("%d messages dropped, the slack queue is full", b.dropped)
+	}
+	return &msg
+}
+
+func (b *batch) empty() bool {
+	return len(b.attachs) == 0 && b.dropped == 0
+}
+
+func sameHeader(a slack.Message, b slack.Message) bool {
+	return a.Username == b.Username && a.Channel == b.Channel && a.IconEmoji == b.IconEmoji && a.IconURL == b.IconURL
+}
+
+// queue sends the queued items from a background worker, one message per batch window.
+type queue struct {
+	client  *slack.Client
+	window  time.Duration
+	onError func(error)
+
+	items   chan item
+	flushes chan chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+
+	mu      sync.Mutex
+	closed  bool
+	dropped int
+}
+
+func newQueue(client *slack.Client, size int, window time.Duration, onError func(error)) *queue {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	if window <= 0 {
+		window = DefaultBatchWindow
+	}
+	if onError == nil {
+		onError = func(err error) {
+			fmt.Fprintf(os.Stderr, "slackrus: cannot send message: %v\n", err)
+		}
+	}
+
+	q := &queue{
+		client:  client,
+		window:  window,
+		onError: onError,
+		items:   make(chan item, size),
+		flushes: make(chan chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// push queues it, dropping it if the queue is full or closed.
+func (q *queue) push(it item) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	select {
+	case q.items <- it:
+	default:
+		q.dropped++
+	}
+}
+
+// flush sends the queued items, waiting until they are sent or ctx is done.
+func (q *queue) flush(ctx context.Context) error {
+	sent := make(chan struct{})
+	select {
+	case q.flushes <- sent:
+	case <-q.done:
+		return errClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-sent:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting items and sends the queued ones, waiting until they
+// are sent or ctx is done.
+func (q *queue) close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.stop)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *queue) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.closed
+}
+
+func (q *queue) run() {
+	defer close(q.done)
+
+	var b batch
+	timer := time.NewTimer(q.window)
+	stopTimer(timer)
+
+	for {
+		select {
+		case it := <-q.items:
+			if !b.accepts(it) {
+				q.send(&b)
+			}
+			if b.empty() {
+				stopTimer(timer)
+				timer.Reset(q.window)
+			}
+			b.add(it)
+
+		case <-timer.C:
+			q.send(&b)
+
+		case sent := <-q.flushes:
+			q.drain(&b)
+			stopTimer(timer)
+			close(sent)
+
+		case <-q.stop:
+			q.drain(&b)
+			stopTimer(timer)
+			return
+		}
+	}
+}
+
+// stopTimer stops t and drains a tick that fired before, so that a Reset
+// does not see a stale tick.
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+// drain sends the batch and all queued items.
+func (q *queue) drain(b *batch) {
+	for {
+		select {
+		case it := <-q.items:
+			if !b.accepts(it) {
+				q.send(b)
+			}
+			b.add(it)
+		default:
+			q.send(b)
+			return
+		}
+	}
+}
+
+// send sends the batch and resets it. Rate limited messages are retried after
+// the Retry-After delay of the response.
+func (q *queue) send(b *batch) {
+	q.mu.Lock()
+	b.dropped, q.dropped = q.dropped, 0
+	q.mu.Unlock()
+
+	if b.empty() {
+		return
+	}
+	msg := b.message()
+	*b = batch{}
+
+	for retry := 0; ; retry++ {
+		err := q.client.SendMessage(msg)
+		if err == nil {
+			return
+		}
+
+		var serr *slack.Error
+		if !errors.As(err, &serr) || serr.Code != 429 || retry == maxRetries {
+			q.onError(err)
+			return
+		}
+
+		wait := serr.RetryAfter
+		if wait <= 0 {
+			wait = time.Second
+		}
+		time.Sleep(wait)
+	}
+}
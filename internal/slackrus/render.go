@@ -0,0 +1,259 @@
+package slackrus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/go-stack/stack"
+	slack "github.com/onmi-bv/commons/internal/slack"
+	logrus "github.com/sirupsen/logrus"
+)
+
+// Message formats.
+const (
+	// FormatAttachment renders the message and fields as a legacy attachment.
+	FormatAttachment = "attachment"
+	// FormatBlocks renders the service, level, caller, error chain, fields and trace as Block Kit blocks.
+	FormatBlocks = "blocks"
+)
+
+// Fields rendered apart from the other fields, as set by logger.FromContext.
+const (
+	fieldTrace        = "trace"
+	fieldSpanID       = "spanId"
+	fieldTraceSampled = "traceSampled"
+)
+
+const (
+	// maxSectionFields is the slack limit of fields per section.
+	maxSectionFields = 10
+	// maxTextLength is the slack limit of a section text.
+	maxTextLength = 3000
+	// maxFieldLength is the slack limit of a section field.
+	maxFieldLength = 2000
+)
+
+// stackSkip lists the packages skipped for locating the caller.
+var stackSkip = []string{
+	"github.com/sirupsen/logrus",
+	"github.com/onmi-bv/commons/internal/slackrus",
+	"github.com/onmi-bv/commons/logger",
+}
+
+// Trace holds the values of the TraceURL template.
+type Trace struct {
+	ProjectID string
+	TraceID   string
+	SpanID    string
+}
+
+// location is the source location of an entry.
+type location struct {
+	File     string
+	Line     int
+	Function string
+}
+
+// color returns the attachment color of level.
+func color(level logrus.Level) string {
+	switch level {
+	case logrus.DebugLevel, logrus.TraceLevel:
+		return "#9B30FF"
+	case logrus.InfoLevel:
+		return "good"
+	case logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel:
+		return "danger"
+	default:
+		return "warning"
+	}
+}
+
+// renderAttachment renders e as a legacy attachment.
+func (sh *Hook) renderAttachment(e *logrus.Entry) *slack.Attachment {
+	attach := &slack.Attachment{}
+
+	// If there are fields we need to render them at attachments
+	if len(e.Data) > 0 {
+
+		// Add a header above field data
+		attach.Text = "Message fields"
+
+		for k, v := range e.Data {
+			slackField := &slack.Field{}
+
+			slackField.Title = k
+			slackField.Value = fmt.Sprint(v)
+			// If the field is <= 20 then we'll set it to short
+			if len(slackField.Value) <= 20 {
+				slackField.Short = true
+			}
+
+			attach.AddField(slackField)
+		}
+		attach.Pretext = e.Message
+	} else {
+		attach.Text = e.Message
+	}
+	attach.Fallback = e.Message
+	attach.Color = color(e.Level)
+	return attach
+}
+
+// renderBlocks renders e, fired at loc, as an attachment of blocks, keeping the color bar of the level.
+func (sh *Hook) renderBlocks(e *logrus.Entry, loc *location) *slack.Attachment {
+	data := logrus.Fields{}
+	for k, v := range e.Data {
+		data[k] = v
+	}
+
+	attach := &slack.Attachment{
+		Fallback: e.Message,
+		Color:    color(e.Level),
+	}
+	attach.AddBlock(slack.NewSection(truncate(fmt.Sprintf("*%s* %s", strings.ToUpper(e.Level.String()), e.Message), maxTextLength)))
+
+	// service and caller
+	var context []string
+	if sh.Service != "" {
+		service := "*" + sh.Service + "*"
+		if sh.Version != "" {
+			service += " `" + sh.Version + "`"
+		}
+		context = append(context, service)
+	}
+	if loc != nil {
+		context = append(context, fmt.Sprintf("`%s:%d` %s", loc.File, loc.Line, loc.Function))
+	}
+	if len(context) > 0 {
+		attach.AddBlock(slack.NewContext(context...))
+	}
+
+	// error chain
+	if v, ok := data[logrus.ErrorKey]; ok {
+		delete(data, logrus.ErrorKey)
+		attach.Fallback = fmt.Sprintf("%s: %v", e.Message, v)
+		attach.AddBlock(slack.NewSection("*Error*\n```" + truncate(strings.Join(errorChain(v), "\n"), maxTextLength-16) + "```"))
+	}
+
+	// trace
+	var trace *Trace
+	if v, ok := data[fieldTrace]; ok {
+		trace = &Trace{ProjectID: sh.projectID(), TraceID: fmt.Sprint(v)}
+		if span, ok := data[fieldSpanID]; ok {
+			trace.SpanID = fmt.Sprint(span)
+		}
+	}
+	delete(data, fieldTrace)
+	delete(data, fieldSpanID)
+	delete(data, fieldTraceSampled)
+
+	// other fields, sorted by key
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > maxSectionFields {
+			n = maxSectionFields
+		}
+		fields := make([]string, n)
+		for i, k := range keys[:n] {
+			fields[i] = truncate(fmt.Sprintf("*%s*\n%v", k, data[k]), maxFieldLength)
+		}
+		attach.AddBlock(slack.NewSection("", fields...))
+		keys = keys[n:]
+	}
+
+	if trace != nil {
+		attach.AddBlock(slack.NewContext(sh.traceLink(trace)))
+	}
+	return attach
+}
+
+// traceLink renders the trace as a link of the TraceURL template, or as its ID
+// if the template is not set or invalid.
+func (sh *Hook) traceLink(trace *Trace) string {
+	plain := "trace `" + trace.TraceID + "`"
+	if sh.TraceURL == "" {
+		return plain
+	}
+
+	tmpl, err := template.New("trace").Parse(sh.TraceURL)
+	if err != nil {
+		return plain
+	}
+	var url bytes.Buffer
+	if err := tmpl.Execute(&url, trace); err != nil {
+		return plain
+	}
+	return fmt.Sprintf("<%s|trace %s>", url.String(), trace.TraceID)
+}
+
+func (sh *Hook) projectID() string {
+	if sh.ProjectID != "" {
+		return sh.ProjectID
+	}
+	return os.Getenv("GOOGLE_CLOUD_PROJECT")
+}
+
+// caller returns the location of e, reported by logrus or located in the call
+// stack as the stackdriver formatter does.
+func caller(e *logrus.Entry) *location {
+	if e.HasCaller() {
+		return &location{File: e.Caller.File, Line: e.Caller.Line, Function: e.Caller.Function}
+	}
+
+	skip := func(pkg string) bool {
+		for _, skip := range stackSkip {
+			if pkg == skip {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := 1; ; i++ {
+		c := stack.Caller(i)
+		// ErrNoFunc indicates we're over traversing the stack.
+		if _, err := c.MarshalText(); err != nil {
+			return nil
+		}
+		pkg := fmt.Sprintf("%+k", c)
+		// Remove vendoring from package path.
+		parts := strings.SplitN(pkg, "/vendor/", 2)
+		pkg = parts[len(parts)-1]
+		if !skip(pkg) {
+			return &location{File: fmt.Sprintf("%+s", c), Line: c.Frame().Line, Function: fmt.Sprintf("%n", c)}
+		}
+	}
+}
+
+// errorChain returns the messages of v and the errors it wraps.
+func errorChain(v interface{}) []string {
+	err, ok := v.(error)
+	if !ok {
+		return []string{fmt.Sprint(v)}
+	}
+
+	var chain []string
+	for ; err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, err.Error())
+	}
+	return chain
+}
+
+// truncate shortens s to n characters, without splitting multi-byte characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-3]) + "..."
+}
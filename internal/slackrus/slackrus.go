@@ -1,9 +1,12 @@
 package slackrus
 
 import (
-	"fmt"
+	"context"
 	"reflect"
+	"sync"
+	"time"
 
+	"github.com/onmi-bv/commons/internal/alerting"
 	slack "github.com/onmi-bv/commons/internal/slack"
 
 	logrus "github.com/sirupsen/logrus"
@@ -20,16 +23,46 @@ type Hook struct {
 	Channel       string `mapstructure:"CHANNEL"`
 	IconEmoji     string `mapstructure:"ICON_EMOJI"`
 	Username      string `mapstructure:"USERNAME"`
-	Asynchronous  bool   `mapstructure:"ASYNCHRONOUS"`
+	// Asynchronous queues the messages, sending them from a background worker.
+	// The entries of a batch window are sent as one message, with repeated
+	// entries sent once with their repeat count.
+	Asynchronous bool `mapstructure:"ASYNCHRONOUS"`
+	// QueueSize limits the queued entries; more are dropped. Defaults to DefaultQueueSize.
+	QueueSize int `mapstructure:"QUEUE_SIZE" default:"1000" validate:"min=0"`
+	// BatchWindow sets the period of entries batched into one message. Defaults to DefaultBatchWindow.
+	BatchWindow time.Duration `mapstructure:"BATCH_WINDOW" default:"2s" validate:"min=0s"`
+	// Format sets the message format, FormatAttachment or FormatBlocks.
+	Format string `mapstructure:"FORMAT" default:"attachment" validate:"oneof=attachment blocks"`
+	// TraceURL is the template of the trace link of the blocks format, with the fields
+	// of Trace, i.e. https://console.cloud.google.com/traces/list?project={{.ProjectID}}&tid={{.TraceID}}
+	TraceURL string `mapstructure:"TRACE_URL"`
+	// ProjectID is the project of the trace link. Defaults to the GOOGLE_CLOUD_PROJECT env.
+	ProjectID string `mapstructure:"PROJECT_ID"`
 	// Extra         map[string]interface{} `mapstructure:"EXTRA"`
 	Disabled bool `mapstructure:"DISABLED"`
+
+	// Service and Version identify the service in the blocks format, set by the logger
+	// to the app name and version.
+	Service string `mapstructure:"-"`
+	Version string `mapstructure:"-"`
+
+	// ErrorHandler is called with the errors of asynchronous messages. Defaults to printing to stderr.
+	ErrorHandler func(error) `mapstructure:"-"`
+
+	queue *queue
 }
 
+// queueMu guards the queues of the hooks, which are created on first use.
+var queueMu sync.Mutex
+
 // NewHook creates a slack Hook with defaults
 func NewHook() Hook {
 	return Hook{
 		Disabled:      true,
 		AcceptedLevel: "warning",
+		Format:        FormatAttachment,
+		QueueSize:     DefaultQueueSize,
+		BatchWindow:   DefaultBatchWindow,
 	}
 }
 
@@ -57,18 +90,57 @@ func (sh *Hook) Fire(e *logrus.Entry) error {
 		return nil
 	}
 
-	color := ""
-	switch e.Level {
-	case logrus.DebugLevel:
-		color = "#9B30FF"
-	case logrus.InfoLevel:
-		color = "good"
-	case logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel:
-		color = "danger"
-	default:
-		color = "warning"
+	var loc *location
+	if sh.Format == FormatBlocks {
+		loc = caller(e)
+	}
+	return sh.send(context.Background(), e, loc)
+}
+
+// Notify implements alerting.Notifier, sending the alert as the entry it was fired
+// for. The logger adds the hook as the slack notifier of its alerting hook.
+func (sh *Hook) Notify(ctx context.Context, a *alerting.Alert) error {
+	if sh.Disabled {
+		return nil
+	}
+
+	level, err := logrus.ParseLevel(a.Level)
+	if err != nil {
+		return err
+	}
+	e := &logrus.Entry{
+		Level:   level,
+		Time:    a.Time,
+		Message: a.Message,
+		Data:    logrus.Fields{},
+	}
+	for k, v := range a.Fields {
+		e.Data[k] = v
+	}
+	if a.Err != nil {
+		e.Data[logrus.ErrorKey] = a.Err
+	} else if a.Error != "" {
+		e.Data[logrus.ErrorKey] = a.Error
 	}
 
+	var loc *location
+	if a.Caller != nil {
+		loc = &location{File: a.Caller.File, Line: a.Caller.Line, Function: a.Caller.Function}
+	}
+	return sh.send(ctx, e, loc)
+}
+
+// Rule returns the alerting rule of the hook, sending the entries of the accepted levels.
+func (sh *Hook) Rule() alerting.Rule {
+	level := sh.AcceptedLevel
+	if level == "" {
+		level = logrus.DebugLevel.String()
+	}
+	return alerting.Rule{Enabled: true, Level: level}
+}
+
+// send renders e, fired at loc, and sends it or queues it if the hook is asynchronous.
+func (sh *Hook) send(ctx context.Context, e *logrus.Entry, loc *location) error {
 	msg := &slack.Message{
 		Username:  sh.Username,
 		Channel:   sh.Channel,
@@ -76,42 +148,61 @@ func (sh *Hook) Fire(e *logrus.Entry) error {
 		IconURL:   sh.IconURL,
 	}
 
-	attach := msg.NewAttachment()
-
-	newEntry := sh.newEntry(e)
-	// If there are fields we need to render them at attachments
-	if len(newEntry.Data) > 0 {
+	if sh.Format == FormatBlocks {
+		msg.AddAttachment(sh.renderBlocks(e, loc))
+	} else {
+		msg.AddAttachment(sh.renderAttachment(sh.newEntry(e)))
+	}
 
-		// Add a header above field data
-		attach.Text = "Message fields"
+	if sh.Asynchronous {
+		header := *msg
+		header.Attachments = nil
+		sh.getQueue().push(item{
+			header: header,
+			attach: msg.Attachments[0],
+			key:    e.Level.String() + ":" + e.Message,
+		})
+		return nil
+	}
 
-		for k, v := range newEntry.Data {
-			slackField := &slack.Field{}
+	return slack.NewClient(sh.HookURL).SendMessageContext(ctx, msg)
+}
 
-			slackField.Title = k
-			slackField.Value = fmt.Sprint(v)
-			// If the field is <= 20 then we'll set it to short
-			if len(slackField.Value) <= 20 {
-				slackField.Short = true
-			}
+// getQueue returns the queue of the hook, creating it if needed.
+// Copies of a hook share its queue once it is created.
+func (sh *Hook) getQueue() *queue {
+	queueMu.Lock()
+	defer queueMu.Unlock()
 
-			attach.AddField(slackField)
-		}
-		attach.Pretext = newEntry.Message
-	} else {
-		attach.Text = newEntry.Message
+	if sh.queue == nil || sh.queue.isClosed() {
+		sh.queue = newQueue(slack.NewClient(sh.HookURL), sh.QueueSize, sh.BatchWindow, sh.ErrorHandler)
 	}
-	attach.Fallback = newEntry.Message
-	attach.Color = color
+	return sh.queue
+}
 
-	c := slack.NewClient(sh.HookURL)
+// Flush sends the queued messages, waiting until they are sent or ctx is done.
+func (sh *Hook) Flush(ctx context.Context) error {
+	queueMu.Lock()
+	q := sh.queue
+	queueMu.Unlock()
 
-	if sh.Asynchronous {
-		go c.SendMessage(msg)
+	if q == nil {
 		return nil
 	}
+	return q.flush(ctx)
+}
+
+// Close sends the queued messages and stops the background worker, waiting until
+// the messages are sent or ctx is done. Entries fired later start a new worker.
+func (sh *Hook) Close(ctx context.Context) error {
+	queueMu.Lock()
+	q := sh.queue
+	queueMu.Unlock()
 
-	return c.SendMessage(msg)
+	if q == nil {
+		return nil
+	}
+	return q.close(ctx)
 }
 
 func (sh *Hook) newEntry(entry *logrus.Entry) *logrus.Entry {
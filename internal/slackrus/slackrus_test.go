@@ -0,0 +1,319 @@
+package slackrus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/onmi-bv/commons/internal/alerting"
+	slack "github.com/onmi-bv/commons/internal/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// webhook is a stand-in for the slack webhook, recording the received messages.
+type webhook struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	messages    []slack.Message
+	rateLimited int // number of requests to answer with 429
+}
+
+func newWebhook(t *testing.T) *webhook {
+	w := &webhook{}
+	w.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		if w.rateLimited > 0 {
+			w.rateLimited--
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		var msg slack.Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("cannot decode message: %v", err)
+		}
+		w.messages = append(w.messages, msg)
+	}))
+	t.Cleanup(w.Close)
+	return w
+}
+
+func (w *webhook) received() []slack.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]slack.Message(nil), w.messages...)
+}
+
+func newTestHook(url string) *Hook {
+	h := NewHook()
+	h.Disabled = false
+	h.HookURL = url
+	h.Asynchronous = true
+	h.BatchWindow = time.Hour
+	h.ErrorHandler = func(err error) {}
+	return &h
+}
+
+func fire(t *testing.T, h *Hook, level logrus.Level, msg string) {
+	if err := h.Fire(&logrus.Entry{Level: level, Message: msg, Data: logrus.Fields{}}); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+}
+
+func TestHookBatch(t *testing.T) {
+	w := newWebhook(t)
+	h := newTestHook(w.URL)
+
+	for i := 0; i < 5; i++ {
+		fire(t, h, logrus.ErrorLevel, "cannot convert request")
+	}
+	fire(t, h, logrus.WarnLevel, "slow request")
+
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	msgs := w.received()
+	if len(msgs) != 1 {
+		t.Fatalf("received %d messages, want 1", len(msgs))
+	}
+	attachs := msgs[0].Attachments
+	if len(attachs) != 2 {
+		t.Fatalf("received %d attachments, want 2", len(attachs))
+	}
+	if attachs[0].Text != "cannot convert request" || attachs[0].Footer != "repeated 5 times" {
+		t.Errorf("first attachment = %q (%q), want repeated error", attachs[0].Text, attachs[0].Footer)
+	}
+	if attachs[1].Text != "slow request" || attachs[1].Footer != "" {
+		t.Errorf("second attachment = %q (%q), want warning", attachs[1].Text, attachs[1].Footer)
+	}
+}
+
+func TestHookWindow(t *testing.T) {
+	w := newWebhook(t)
+	h := newTestHook(w.URL)
+	h.BatchWindow = 20 * time.Millisecond
+	defer h.Close(context.Background())
+
+	fire(t, h, logrus.ErrorLevel, "first")
+
+	deadline := time.Now().Add(time.Second)
+	for len(w.received()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(w.received()) != 1 {
+		t.Fatal("batch was not sent after the window")
+	}
+}
+
+func TestHookRetryAfter(t *testing.T) {
+	w := newWebhook(t)
+	w.rateLimited = 1
+	h := newTestHook(w.URL)
+
+	fire(t, h, logrus.ErrorLevel, "rate limited")
+
+	start := time.Now()
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if time.Since(start) < time.Second {
+		t.Error("Flush() did not wait for Retry-After")
+	}
+	if len(w.received()) != 1 {
+		t.Errorf("received %d messages after retry, want 1", len(w.received()))
+	}
+	h.Close(context.Background())
+}
+
+func TestQueueFull(t *testing.T) {
+	w := newWebhook(t)
+
+	// a queue without a worker yet
+	q := &queue{
+		client:  slack.NewClient(w.URL),
+		window:  time.Hour,
+		onError: func(err error) {},
+		items:   make(chan item, 1),
+		flushes: make(chan chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for i := 0; i < 3; i++ {
+		q.push(item{attach: &slack.Attachment{Text: "entry"}, key: "entry"})
+	}
+	go q.run()
+
+	if err := q.close(context.Background()); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	msgs := w.received()
+	if len(msgs) != 1 || msgs[0].Text != "2 messages dropped, the slack queue is full" {
+		t.Errorf("received %+v, want a note of the dropped messages", msgs)
+	}
+}
+
+func TestHookSynchronous(t *testing.T) {
+	w := newWebhook(t)
+	h := newTestHook(w.URL)
+	h.Asynchronous = false
+
+	fire(t, h, logrus.ErrorLevel, "sync")
+	if len(w.received()) != 1 {
+		t.Errorf("received %d messages, want 1", len(w.received()))
+	}
+}
+
+func TestHookBlocks(t *testing.T) {
+	w := newWebhook(t)
+	h := newTestHook(w.URL)
+	h.Asynchronous = false
+	h.Format = FormatBlocks
+	h.Service, h.Version = "api", "1.2.3"
+	h.ProjectID = "project"
+	h.TraceURL = "https://console.cloud.google.com/traces/list?project={{.ProjectID}}&tid={{.TraceID}}"
+
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.SetReportCaller(true)
+	l.AddHook(h)
+
+	cause := errors.New("connection refused")
+	l.WithError(fmt.Errorf("cannot query: %w", cause)).
+		WithFields(logrus.Fields{"trace": "abc123", "spanId": "1", "user": "bob"}).
+		Error("cannot convert request")
+
+	msgs := w.received()
+	if len(msgs) != 1 || len(msgs[0].Attachments) != 1 {
+		t.Fatalf("received %+v, want one attachment", msgs)
+	}
+	attach := msgs[0].Attachments[0]
+	if attach.Color != "danger" || attach.Fallback != "cannot convert request: cannot query: connection refused" {
+		t.Errorf("attachment = %q (%q), want danger fallback with the error", attach.Fallback, attach.Color)
+	}
+
+	var texts []string
+	for _, b := range attach.Blocks {
+		if b.Text != nil {
+			texts = append(texts, b.Text.Text)
+		}
+		for _, t := range append(b.Fields, b.Elements...) {
+			texts = append(texts, t.Text)
+		}
+	}
+	want := []string{
+		"*ERROR* cannot convert request",
+		"*api* `1.2.3`",
+		"slackrus_test.go:",
+		"*Error*\n```cannot query: connection refused\nconnection refused```",
+		"*user*\nbob",
+		"<https://console.cloud.google.com/traces/list?project=project&tid=abc123|trace abc123>",
+	}
+	all := strings.Join(texts, "\n")
+	for _, s := range want {
+		if !strings.Contains(all, s) {
+			t.Errorf("blocks do not contain %q:\n%s", s, all)
+		}
+	}
+	if strings.Contains(all, "spanId") {
+		t.Errorf("blocks contain the span field:\n%s", all)
+	}
+}
+
+func TestHookNotify(t *testing.T) {
+	w := newWebhook(t)
+	h := newTestHook(w.URL)
+	h.Format = FormatBlocks
+	h.AcceptedLevel = "error"
+
+	alerts := alerting.NewHook("api", "1.2.3", 10, time.Second, nil)
+	if err := alerts.Add("slack", h.Rule(), h); err != nil {
+		t.Fatal(err)
+	}
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.AddHook(alerts)
+
+	l.Warn("not sent")
+	l.WithError(fmt.Errorf("cannot query: %w", errors.New("connection refused"))).WithField("user", "bob").Error("cannot convert request")
+	l.Error("cannot convert request")
+
+	// closing the alerting hook sends the batch of the slack queue
+	if err := alerts.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	msgs := w.received()
+	if len(msgs) != 1 || len(msgs[0].Attachments) != 1 {
+		t.Fatalf("received %+v, want one deduplicated attachment", msgs)
+	}
+
+	var texts []string
+	for _, b := range msgs[0].Attachments[0].Blocks {
+		if b.Text != nil {
+			texts = append(texts, b.Text.Text)
+		}
+		for _, t := range append(b.Fields, b.Elements...) {
+			texts = append(texts, t.Text)
+		}
+	}
+	all := strings.Join(texts, "\n")
+	for _, s := range []string{"*ERROR* cannot convert request", "slackrus_test.go:", "connection refused```", "*user*\nbob", "repeated 2 times"} {
+		if !strings.Contains(all, s) {
+			t.Errorf("blocks do not contain %q:\n%s", s, all)
+		}
+	}
+}
+
+func TestHookBlocksRepeated(t *testing.T) {
+	w := newWebhook(t)
+	h := newTestHook(w.URL)
+	h.Format = FormatBlocks
+
+	for i := 0; i < 3; i++ {
+		fire(t, h, logrus.ErrorLevel, "repeated")
+	}
+	h.Close(context.Background())
+
+	msgs := w.received()
+	if len(msgs) != 1 || len(msgs[0].Attachments) != 1 {
+		t.Fatalf("received %+v, want one attachment", msgs)
+	}
+	blocks := msgs[0].Attachments[0].Blocks
+	last := blocks[len(blocks)-1]
+	if last.Type != slack.ContextBlock || last.Elements[0].Text != "repeated 3 times" {
+		t.Errorf("last block = %+v, want the repeat count", last)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"short", "short"},
+		{"héllo wörld", "héllo ..."},
+		{"日本語のテキストです", "日本語のテキ..."},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.s, 9)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, 9) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
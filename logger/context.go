@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"context"
+	"sync"
+
+	logger "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Fields set on the entries of FromContext. The trace fields are lifted into
+// the Cloud Logging trace fields by the sd formatter.
+const (
+	FieldApp          = "app"
+	FieldVersion      = "version"
+	FieldTrace        = "trace"
+	FieldSpanID       = "spanId"
+	FieldTraceSampled = "traceSampled"
+)
+
+type entryKey struct{}
+
+var (
+	baseMu sync.RWMutex
+	base   = logger.NewEntry(logger.StandardLogger())
+)
+
+// setBase sets the entry FromContext starts from if the context has none.
+func setBase(e *logger.Entry) {
+	baseMu.Lock()
+	defer baseMu.Unlock()
+
+	base = e
+}
+
+// FromContext returns the entry stored in ctx by IntoContext, or else an entry of the
+// standard logger, as configured by InitializeStandard. The entry has the app name and version, and the trace, spanId and
+// traceSampled fields of the OpenTelemetry span in ctx.
+func FromContext(ctx context.Context) *logger.Entry {
+	e, ok := ctx.Value(entryKey{}).(*logger.Entry)
+
+	baseMu.RLock()
+	b := base
+	baseMu.RUnlock()
+
+	if !ok {
+		e = b
+	}
+
+	fields := logger.Fields{}
+	for _, k := range []string{FieldApp, FieldVersion} {
+		if _, ok := e.Data[k]; !ok {
+			if v, ok := b.Data[k]; ok {
+				fields[k] = v
+			}
+		}
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields[FieldTrace] = sc.TraceID().String()
+		fields[FieldSpanID] = sc.SpanID().String()
+		fields[FieldTraceSampled] = sc.IsSampled()
+	}
+
+	return e.WithContext(ctx).WithFields(fields)
+}
+
+// IntoContext returns a copy of ctx carrying e, i.e. with the fields of a request.
+func IntoContext(ctx context.Context, e *logger.Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, e)
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestFromContext(t *testing.T) {
+	setBase(logger.WithFields(logger.Fields{FieldApp: "app", FieldVersion: "1.0.0"}))
+	defer setBase(logger.NewEntry(logger.StandardLogger()))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	spanCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want logger.Fields
+	}{
+		{
+			name: "empty context",
+			ctx:  context.Background(),
+			want: logger.Fields{FieldApp: "app", FieldVersion: "1.0.0"},
+		},
+		{
+			name: "span",
+			ctx:  spanCtx,
+			want: logger.Fields{
+				FieldApp:          "app",
+				FieldVersion:      "1.0.0",
+				FieldTrace:        "4bf92f3577b34da6a3ce929d0e0e4736",
+				FieldSpanID:       "00f067aa0ba902b7",
+				FieldTraceSampled: true,
+			},
+		},
+		{
+			name: "entry in context",
+			ctx:  IntoContext(context.Background(), logger.WithField("request", "r1")),
+			want: logger.Fields{FieldApp: "app", FieldVersion: "1.0.0", "request": "r1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := FromContext(tt.ctx)
+			if len(e.Data) != len(tt.want) {
+				t.Errorf("FromContext() fields = %v, want %v", e.Data, tt.want)
+			}
+			for k, v := range tt.want {
+				if e.Data[k] != v {
+					t.Errorf("FromContext() %s = %v, want %v", k, e.Data[k], v)
+				}
+			}
+			if e.Context != tt.ctx {
+				t.Error("FromContext() entry has no context")
+			}
+		})
+	}
+}
+
+func TestFromContextInit(t *testing.T) {
+	std := logger.StandardLogger()
+	defer std.ReplaceHooks(std.ReplaceHooks(make(logger.LevelHooks)))
+	defer std.SetFormatter(std.Formatter)
+	defer std.SetOutput(std.Out)
+	defer setBase(logger.NewEntry(std))
+
+	t.Setenv("CTXLOG_FORMATTER", "json")
+	l, err := Init(context.Background(), Configuration{AppName: "app", Version: "1.0.0", Prefix: "ctxlog", Standard: true})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	var out bytes.Buffer
+	l.SetOutput(&out)
+
+	// a dedicated logger, i.e. of a library, does not take over FromContext
+	lib := NewLogger()
+	lib.Output = "discard"
+	if _, err := lib.Initialize(context.Background(), "lib", "2.0.0"); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	FromContext(context.Background()).Info("through the logger")
+
+	var e map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+		t.Fatalf("cannot decode %q, want a json entry of the logger: %v", out.String(), err)
+	}
+	if e["msg"] != "through the logger" || e[FieldApp] != "app" || e[FieldVersion] != "1.0.0" {
+		t.Errorf("entry = %v, want the message with the app fields", e)
+	}
+}
@@ -3,33 +3,50 @@ module github.com/onmi-bv/commons/logger
 go 1.19
 
 require (
-	github.com/evalphobia/logrus_fluent v0.5.4
 	github.com/go-stack/stack v1.8.1
 	github.com/onmi-bv/commons/confighelper v0.0.0-20230107122636-6b6bd027401a
+	github.com/onmi-bv/commons/internal/alerting v0.0.0-00010101000000-000000000000
 	github.com/onmi-bv/commons/internal/slackrus v0.0.0-20230107122636-6b6bd027401a
 	github.com/sirupsen/logrus v1.9.0
+	github.com/tinylib/msgp v1.1.8
+	go.opentelemetry.io/otel v1.12.0
+	go.opentelemetry.io/otel/trace v1.12.0
+	go.opentelemetry.io/proto/otlp v0.19.0
+	google.golang.org/grpc v1.52.0
+	google.golang.org/protobuf v1.28.1
 )
 
 require (
-	github.com/bmizerany/assert v0.0.0-20160611221934-b7ed37b82869 // indirect
-	github.com/fluent/fluent-logger-golang v1.9.0 // indirect
-	github.com/fsnotify/fsnotify v1.5.1 // indirect
+	github.com/fsnotify/fsnotify v1.6.0 // indirect
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.7.0 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
-	github.com/magiconair/properties v1.8.5 // indirect
+	github.com/magiconair/properties v1.8.7 // indirect
 	github.com/mitchellh/go-homedir v1.1.0 // indirect
-	github.com/mitchellh/mapstructure v1.4.3 // indirect
-	github.com/onmi-bv/commons/internal/slack v0.0.0-20200714091344-4f344a90354c // indirect
-	github.com/pelletier/go-toml v1.9.4 // indirect
+	github.com/mitchellh/mapstructure v1.5.0 // indirect
+	github.com/onmi-bv/commons/internal/slack v0.0.0-20230107122636-6b6bd027401a // indirect
+	github.com/pelletier/go-toml/v2 v2.0.6 // indirect
 	github.com/philhofer/fwd v1.1.2 // indirect
-	github.com/spf13/afero v1.6.0 // indirect
-	github.com/spf13/cast v1.4.1 // indirect
+	github.com/spf13/afero v1.9.3 // indirect
+	github.com/spf13/cast v1.5.0 // indirect
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	github.com/spf13/viper v1.10.1 // indirect
-	github.com/subosito/gotenv v1.2.0 // indirect
-	github.com/tinylib/msgp v1.1.8 // indirect
+	github.com/spf13/viper v1.15.0 // indirect
+	github.com/subosito/gotenv v1.4.2 // indirect
+	golang.org/x/net v0.4.0 // indirect
 	golang.org/x/sys v0.3.0 // indirect
 	golang.org/x/text v0.5.0 // indirect
-	gopkg.in/ini.v1 v1.66.2 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
+	google.golang.org/genproto v0.0.0-20221227171554-f9683d7f8bef // indirect
+	gopkg.in/ini.v1 v1.67.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/onmi-bv/commons/confighelper => ../confighelper
+
+replace github.com/onmi-bv/commons/internal/slackrus => ../internal/slackrus
+
+replace github.com/onmi-bv/commons/internal/slack => ../internal/slack
+
+replace github.com/onmi-bv/commons/internal/alerting => ../internal/alerting
@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FieldHTTPRequest is the field of the request entries of HTTPMiddleware, lifted into
+// the Cloud Logging httpRequest by the sd formatter.
+const FieldHTTPRequest = "httpRequest"
+
+// cloudTraceHeader is the trace header of Google Cloud load balancers and Cloud Run.
+const cloudTraceHeader = "X-Cloud-Trace-Context"
+
+// HTTPMiddleware wraps next to log an entry per request with the method, URL, status,
+// response size, latency, remote IP and user agent as the Cloud Logging HttpRequest.
+// The entry is linked to the span of the request context, or else to the trace of the
+// request headers, which is also set on the context of next. Entries of server errors
+// are logged at error level, of client errors at warning level and others at info level.
+//
+// I.e., use it with the mux of the cloudevents receiver:
+//
+//	client.WithMiddleware(logger.HTTPMiddleware)
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		ctx := r.Context()
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+		}
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			if sc, ok := parseCloudTrace(r.Header.Get(cloudTraceHeader)); ok {
+				ctx = trace.ContextWithRemoteSpanContext(ctx, sc)
+			}
+		}
+		r = r.WithContext(ctx)
+
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		req := map[string]interface{}{
+			"requestMethod": r.Method,
+			"requestUrl":    requestURL(r),
+			"status":        rw.status,
+			"responseSize":  strconv.FormatInt(rw.size, 10),
+			"userAgent":     r.UserAgent(),
+			"remoteIp":      remoteIP(r),
+			"protocol":      r.Proto,
+			"latency":       fmt.Sprintf("%.9fs", time.Since(start).Seconds()),
+		}
+		if r.ContentLength > 0 {
+			req["requestSize"] = strconv.FormatInt(r.ContentLength, 10)
+		}
+		if referer := r.Referer(); referer != "" {
+			req["referer"] = referer
+		}
+
+		level := logger.InfoLevel
+		switch {
+		case rw.status >= 500:
+			level = logger.ErrorLevel
+		case rw.status >= 400:
+			level = logger.WarnLevel
+		}
+		FromContext(ctx).WithField(FieldHTTPRequest, req).Logf(level, "%s %s %d", r.Method, r.URL.Path, rw.status)
+	})
+}
+
+// responseWriter records the status and size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher if the wrapped writer does.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// requestURL returns the absolute URL of r.
+func requestURL(r *http.Request) string {
+	if r.URL.IsAbs() {
+		return r.URL.String()
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+// remoteIP returns the client IP of r, the first of X-Forwarded-For behind a proxy.
+func remoteIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		return strings.TrimSpace(strings.Split(fwd, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// parseCloudTrace parses a X-Cloud-Trace-Context header, "TRACE_ID/SPAN_ID;o=OPTIONS"
+// with a decimal span ID.
+func parseCloudTrace(h string) (trace.SpanContext, bool) {
+	if h == "" {
+		return trace.SpanContext{}, false
+	}
+	h, options, _ := strings.Cut(h, ";")
+	traceHex, spanDec, _ := strings.Cut(h, "/")
+
+	traceID, err := trace.TraceIDFromHex(traceHex)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	n, err := strconv.ParseUint(spanDec, 10, 64)
+	if err != nil || n == 0 {
+		return trace.SpanContext{}, false
+	}
+	var spanID trace.SpanID
+	for i := range spanID {
+		spanID[i] = byte(n >> (56 - 8*i))
+	}
+
+	config := trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, Remote: true}
+	if options == "o=1" {
+		config.TraceFlags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(config), true
+}
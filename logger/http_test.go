@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	stackdriver "github.com/onmi-bv/commons/logger/internals/sdformatter"
+	logger "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	var out bytes.Buffer
+	l := logger.New()
+	l.SetOutput(&out)
+	l.SetFormatter(stackdriver.NewFormatter(stackdriver.WithProjectID("project")))
+	setBase(logger.NewEntry(l))
+	defer setBase(logger.NewEntry(logger.StandardLogger()))
+
+	var handlerTrace string
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerTrace = trace.SpanContextFromContext(r.Context()).TraceID().String()
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/events?id=1", strings.NewReader("{}"))
+	r.Header.Set("User-Agent", "test")
+	r.Header.Set("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
+	r.Header.Set(cloudTraceHeader, "4bf92f3577b34da6a3ce929d0e0e4736/1;o=1")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if handlerTrace != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("handler trace = %q, want the trace of the header", handlerTrace)
+	}
+
+	var e struct {
+		Severity     string                 `json:"severity"`
+		Message      string                 `json:"message"`
+		HTTPRequest  map[string]interface{} `json:"httpRequest"`
+		Trace        string                 `json:"logging.googleapis.com/trace"`
+		SpanID       string                 `json:"logging.googleapis.com/spanId"`
+		TraceSampled bool                   `json:"logging.googleapis.com/trace_sampled"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+		t.Fatalf("cannot decode %s: %v", out.Bytes(), err)
+	}
+
+	if e.Severity != "WARNING" || e.Message != "POST /events 418" {
+		t.Errorf("entry = %s %q, want a warning of the request", e.Severity, e.Message)
+	}
+	if e.Trace != "projects/project/traces/4bf92f3577b34da6a3ce929d0e0e4736" || e.SpanID != "0000000000000001" || !e.TraceSampled {
+		t.Errorf("trace = %s %s %v, want the trace of the header", e.Trace, e.SpanID, e.TraceSampled)
+	}
+
+	want := map[string]interface{}{
+		"requestMethod": "POST",
+		"requestUrl":    "http://example.com/events?id=1",
+		"requestSize":   "2",
+		"status":        float64(418),
+		"responseSize":  "15",
+		"userAgent":     "test",
+		"remoteIp":      "10.0.0.1",
+		"protocol":      "HTTP/1.1",
+	}
+	for k, v := range want {
+		if e.HTTPRequest[k] != v {
+			t.Errorf("httpRequest.%s = %v, want %v", k, e.HTTPRequest[k], v)
+		}
+	}
+	if latency, _ := e.HTTPRequest["latency"].(string); !strings.HasSuffix(latency, "s") {
+		t.Errorf("httpRequest.latency = %q, want a duration in seconds", latency)
+	}
+}
+
+func TestParseCloudTrace(t *testing.T) {
+	tests := []struct {
+		header  string
+		ok      bool
+		spanID  string
+		sampled bool
+	}{
+		{"4bf92f3577b34da6a3ce929d0e0e4736/12345;o=1", true, "0000000000003039", true},
+		{"4bf92f3577b34da6a3ce929d0e0e4736/12345", true, "0000000000003039", false},
+		{"4bf92f3577b34da6a3ce929d0e0e4736", false, "", false},
+		{"invalid/1;o=1", false, "", false},
+		{"", false, "", false},
+	}
+	for _, tt := range tests {
+		sc, ok := parseCloudTrace(tt.header)
+		if ok != tt.ok {
+			t.Errorf("parseCloudTrace(%q) ok = %v, want %v", tt.header, ok, tt.ok)
+			continue
+		}
+		if ok && (sc.SpanID().String() != tt.spanID || sc.IsSampled() != tt.sampled) {
+			t.Errorf("parseCloudTrace(%q) = %s %v, want %s %v", tt.header, sc.SpanID(), sc.IsSampled(), tt.spanID, tt.sampled)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package forward
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// eventTimeType is the msgpack extension type of EventTime.
+const eventTimeType = 0
+
+// EventTime is the nanosecond precision time of the forward protocol, a msgpack
+// extension of the seconds and nanoseconds as big endian uint32.
+type EventTime struct {
+	time.Time
+}
+
+// ExtensionType implements msgp.Extension.
+func (t *EventTime) ExtensionType() int8 {
+	return eventTimeType
+}
+
+// Len implements msgp.Extension.
+func (t *EventTime) Len() int {
+	return 8
+}
+
+// MarshalBinaryTo implements msgp.Extension.
+func (t *EventTime) MarshalBinaryTo(b []byte) error {
+	binary.BigEndian.PutUint32(b, uint32(t.Unix()))
+	binary.BigEndian.PutUint32(b[4:], uint32(t.Nanosecond()))
+	return nil
+}
+
+// UnmarshalBinary implements msgp.Extension.
+func (t *EventTime) UnmarshalBinary(b []byte) error {
+	if len(b) != 8 {
+		return fmt.Errorf("invalid event time length %d", len(b))
+	}
+	sec := binary.BigEndian.Uint32(b)
+	nsec := binary.BigEndian.Uint32(b[4:])
+	t.Time = time.Unix(int64(sec), int64(nsec))
+	return nil
+}
@@ -0,0 +1,604 @@
+// Package forward is a logrus hook sending entries to fluentd with the forward protocol.
+//
+// Entries are buffered in memory and, when the memory buffer is full, in a spill file if
+// set. A background worker sends them in batches, reconnecting with backoff when fluentd
+// is down. Entries that cannot be buffered are written to the fallback writer, i.e.
+// stdout, as JSON lines. See https://github.com/fluent/fluentd/wiki/Forward-Protocol-Specification-v1.
+package forward
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Config defaults.
+const (
+	DefaultBufferSize    = 10000
+	DefaultSpillMaxBytes = 64 << 20
+	DefaultTimeout       = 5 * time.Second
+	DefaultMinBackoff    = 100 * time.Millisecond
+	DefaultMaxBackoff    = 30 * time.Second
+
+	// maxBatch limits the entries sent in one message.
+	maxBatch = 500
+)
+
+// Record fields, as set by logrus_fluent.
+const (
+	TagField     = "tag"
+	MessageField = "message"
+	LevelField   = "level"
+)
+
+// Config configures the hook.
+type Config struct {
+	Host string
+	Port int
+
+	// Tag is the fluentd tag of the entries. The tag field of an entry overrides it;
+	// if both are empty, the message is the tag.
+	Tag string
+
+	// BufferSize limits the entries buffered in memory.
+	BufferSize int
+
+	// SpillPath is the file buffering the entries beyond BufferSize. Empty disables it.
+	// Entries left in the file are sent after a restart.
+	SpillPath string
+
+	// SpillMaxBytes limits the size of the spill file.
+	SpillMaxBytes int64
+
+	// Timeout limits connecting, writing a batch and waiting for its ack.
+	Timeout time.Duration
+
+	// MinBackoff and MaxBackoff bound the delay between reconnects, doubled per failure.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// RequireAck waits for fluentd to acknowledge each batch, resending unacknowledged ones.
+	RequireAck bool
+
+	// Fallback receives the entries that cannot be buffered, and the buffered ones
+	// not sent on Close, as JSON lines. Nil drops them.
+	Fallback io.Writer
+
+	// Levels sets the levels sent. Defaults to panic to info.
+	Levels []logrus.Level
+}
+
+// Metrics counts the entries of the hook.
+type Metrics struct {
+	Sent       uint64 // entries sent to fluentd
+	Buffered   int    // entries buffered in memory
+	Spilled    int    // entries buffered in the spill file
+	Fallback   uint64 // entries written to the fallback writer
+	Dropped    uint64 // entries lost
+	Failures   uint64 // failed connects and sends
+	Reconnects uint64 // connections made after a failure
+	Connected  bool
+}
+
+// Hook is a logrus hook sending entries to fluentd.
+type Hook struct {
+	config Config
+	addr   string
+
+	mu      sync.Mutex
+	memory  [][]byte // encoded items, see encode
+	spill   *spill
+	metrics Metrics
+	closed  bool
+
+	notify  chan struct{}
+	closing chan struct{}
+	abort   chan struct{}
+	done    chan struct{}
+
+	conn   net.Conn
+	failed bool // the last connection failed
+}
+
+// New creates a hook and starts its worker. It does not connect; entries are
+// buffered until fluentd is reachable.
+func New(config Config) (*Hook, error) {
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultBufferSize
+	}
+	if config.SpillMaxBytes <= 0 {
+		config.SpillMaxBytes = DefaultSpillMaxBytes
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = DefaultMinBackoff
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	if len(config.Levels) == 0 {
+		config.Levels = []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel, logrus.InfoLevel}
+	}
+
+	h := &Hook{
+		config:  config,
+		addr:    net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		notify:  make(chan struct{}, 1),
+		closing: make(chan struct{}),
+		abort:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if config.SpillPath != "" {
+		s, err := openSpill(config.SpillPath, config.SpillMaxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open spill file: %v", err)
+		}
+		h.spill = s
+		h.metrics.Spilled = s.count
+	}
+
+	go h.run()
+	if h.metrics.Spilled > 0 {
+		h.wake()
+	}
+	return h, nil
+}
+
+// Levels implements logrus.Hook.
+func (h *Hook) Levels() []logrus.Level {
+	return h.config.Levels
+}
+
+// Fire implements logrus.Hook, buffering the entry.
+func (h *Hook) Fire(e *logrus.Entry) error {
+	item, err := h.encode(e)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case h.closed:
+		h.fallback(item)
+	// entries follow the spilled ones, keeping the order
+	case h.spill != nil && h.spill.count > 0:
+		h.push(item)
+	case len(h.memory) < h.config.BufferSize:
+		h.memory = append(h.memory, item)
+		h.metrics.Buffered++
+	default:
+		h.push(item)
+	}
+	h.wake()
+	return nil
+}
+
+// push spills item, or writes it to the fallback if the spill file is full or disabled.
+func (h *Hook) push(item []byte) {
+	if h.spill == nil {
+		h.fallback(item)
+		return
+	}
+	ok, err := h.spill.push(item)
+	if err != nil || !ok {
+		h.fallback(item)
+		return
+	}
+	h.metrics.Spilled++
+}
+
+// fallback writes item to the fallback writer, or drops it.
+func (h *Hook) fallback(item []byte) {
+	if h.config.Fallback == nil {
+		h.metrics.Dropped++
+		return
+	}
+	line, err := jsonLine(item)
+	if err == nil {
+		_, err = h.config.Fallback.Write(line)
+	}
+	if err != nil {
+		h.metrics.Dropped++
+		return
+	}
+	h.metrics.Fallback++
+}
+
+// Metrics returns the counts of the entries.
+func (h *Hook) Metrics() Metrics {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.metrics
+}
+
+// Close sends the buffered entries and stops the worker, waiting until they are
+// sent or ctx is done. The entries left in memory are written to the fallback
+// writer; the spilled ones stay in the spill file.
+func (h *Hook) Close(ctx context.Context) error {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.closing)
+	}
+	h.mu.Unlock()
+
+	var err error
+	select {
+	case <-h.done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		h.mu.Lock()
+		select {
+		case <-h.abort:
+		default:
+			close(h.abort)
+		}
+		h.mu.Unlock()
+		<-h.done
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, item := range h.memory {
+		h.fallback(item)
+	}
+	h.memory = nil
+	h.metrics.Buffered = 0
+	if h.spill != nil {
+		if cerr := h.spill.close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (h *Hook) wake() {
+	select {
+	case h.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run sends the buffered entries until the hook is closed and they are sent, or
+// until the hook is aborted.
+func (h *Hook) run() {
+	defer close(h.done)
+	defer h.disconnect()
+
+	backoff := h.config.MinBackoff
+	for {
+		batch, next, err := h.next()
+		if err != nil {
+			// the spill file cannot be read; its entries are lost
+			h.mu.Lock()
+			h.metrics.Dropped += uint64(h.spill.count)
+			h.metrics.Spilled = 0
+			h.spill.reset()
+			h.mu.Unlock()
+			continue
+		}
+
+		if len(batch) == 0 {
+			select {
+			case <-h.notify:
+				continue
+			case <-h.closing:
+				return
+			case <-h.abort:
+				return
+			}
+		}
+
+		if err := h.send(batch); err != nil {
+			h.disconnect()
+			h.mu.Lock()
+			h.metrics.Failures++
+			h.mu.Unlock()
+
+			select {
+			case <-h.closing:
+				// give up on the entries, which Close writes to the fallback
+				return
+			default:
+			}
+
+			select {
+			case <-time.After(backoff):
+			case <-h.closing:
+			case <-h.abort:
+				return
+			}
+			if backoff *= 2; backoff > h.config.MaxBackoff {
+				backoff = h.config.MaxBackoff
+			}
+			continue
+		}
+		backoff = h.config.MinBackoff
+		h.commit(len(batch), next)
+	}
+}
+
+// next returns the oldest buffered entries with the same tag, from memory or
+// else from the spill file, with the spill offset after them.
+func (h *Hook) next() (batch [][]byte, next int64, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	select {
+	case <-h.abort:
+		return nil, 0, nil
+	default:
+	}
+
+	if len(h.memory) > 0 {
+		return sameTag(h.memory), 0, nil
+	}
+	if h.spill != nil && h.spill.count > 0 {
+		items, offsets, err := h.spill.peek(maxBatch)
+		if err != nil {
+			return nil, 0, err
+		}
+		batch = sameTag(items)
+		return batch, offsets[len(batch)-1], nil
+	}
+	return nil, 0, nil
+}
+
+// commit removes the n sent entries.
+func (h *Hook) commit(n int, next int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.metrics.Sent += uint64(n)
+	if len(h.memory) > 0 {
+		h.memory = h.memory[n:]
+		h.metrics.Buffered -= n
+		return
+	}
+	h.spill.commit(n, next)
+	h.metrics.Spilled -= n
+}
+
+// sameTag returns the first items of the same tag, up to maxBatch.
+func sameTag(items [][]byte) [][]byte {
+	tag, _, _ := msgp.ReadStringBytes(items[0])
+	n := 1
+	for ; n < len(items) && n < maxBatch; n++ {
+		if t, _, _ := msgp.ReadStringBytes(items[n]); t != tag {
+			break
+		}
+	}
+	return items[:n]
+}
+
+// send writes batch as a forward mode message, waiting for its ack if required.
+func (h *Hook) send(batch [][]byte) error {
+	if err := h.connect(); err != nil {
+		return err
+	}
+
+	tag, _, _ := msgp.ReadStringBytes(batch[0])
+	options := 1
+	if h.config.RequireAck {
+		options = 2
+	}
+
+	msg := msgp.AppendArrayHeader(nil, 3)
+	msg = msgp.AppendString(msg, tag)
+	msg = msgp.AppendArrayHeader(msg, uint32(len(batch)))
+	for _, item := range batch {
+		_, entry, _ := msgp.ReadStringBytes(item)
+		msg = append(msg, entry...)
+	}
+	msg = msgp.AppendMapHeader(msg, uint32(options))
+	msg = msgp.AppendString(msg, "size")
+	msg = msgp.AppendInt(msg, len(batch))
+
+	var chunk string
+	if h.config.RequireAck {
+		chunk = newChunkID()
+		msg = msgp.AppendString(msg, "chunk")
+		msg = msgp.AppendString(msg, chunk)
+	}
+
+	h.conn.SetDeadline(time.Now().Add(h.config.Timeout))
+	if _, err := h.conn.Write(msg); err != nil {
+		return fmt.Errorf("cannot write to fluentd: %v", err)
+	}
+	if !h.config.RequireAck {
+		return nil
+	}
+
+	ack, err := readAck(h.conn)
+	if err != nil {
+		return fmt.Errorf("cannot read fluentd ack: %v", err)
+	}
+	if ack != chunk {
+		return fmt.Errorf("unexpected fluentd ack %q for chunk %q", ack, chunk)
+	}
+	return nil
+}
+
+func (h *Hook) connect() error {
+	if h.conn != nil {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("tcp", h.addr, h.config.Timeout)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		h.failed = true
+		return fmt.Errorf("cannot connect to fluentd: %v", err)
+	}
+	if h.failed {
+		h.metrics.Reconnects++
+		h.failed = false
+	}
+	h.conn = conn
+	h.metrics.Connected = true
+	return nil
+}
+
+func (h *Hook) disconnect() {
+	if h.conn == nil {
+		return
+	}
+	h.conn.Close()
+	h.conn = nil
+
+	h.mu.Lock()
+	h.failed = true
+	h.metrics.Connected = false
+	h.mu.Unlock()
+}
+
+// readAck reads the chunk of an ack response.
+func readAck(conn net.Conn) (string, error) {
+	r := msgp.NewReader(conn)
+	sz, err := r.ReadMapHeader()
+	if err != nil {
+		return "", err
+	}
+	var ack string
+	for i := uint32(0); i < sz; i++ {
+		key, err := r.ReadString()
+		if err != nil {
+			return "", err
+		}
+		if key != "ack" {
+			if err := r.Skip(); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if ack, err = r.ReadString(); err != nil {
+			return "", err
+		}
+	}
+	return ack, nil
+}
+
+func newChunkID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// encode encodes e as an item: the tag followed by the [time, record] entry.
+func (h *Hook) encode(e *logrus.Entry) ([]byte, error) {
+	record := make(map[string]interface{}, len(e.Data)+2)
+	for k, v := range e.Data {
+		record[k] = value(v)
+	}
+
+	tag := h.config.Tag
+	if t, ok := record[TagField].(string); ok {
+		tag = t
+		delete(record, TagField)
+	}
+	if tag == "" {
+		tag = e.Message
+	}
+	record[LevelField] = e.Level.String()
+	if _, ok := record[MessageField]; !ok {
+		record[MessageField] = e.Message
+	}
+
+	t := e.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	b := msgp.AppendString(nil, tag)
+	b = msgp.AppendArrayHeader(b, 2)
+	b, err := msgp.AppendExtension(b, &EventTime{t})
+	if err != nil {
+		return nil, err
+	}
+	b, err = msgp.AppendIntf(b, record)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode log entry: %v", err)
+	}
+	return b, nil
+}
+
+// value converts v to a type encoded by msgp, through JSON for other types.
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, string, []byte,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var j interface{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return fmt.Sprint(v)
+	}
+	return j
+}
+
+// decode returns the tag, time and record of an item.
+func decode(item []byte) (tag string, t time.Time, record map[string]interface{}, err error) {
+	tag, b, err := msgp.ReadStringBytes(item)
+	if err != nil {
+		return
+	}
+	if _, b, err = msgp.ReadArrayHeaderBytes(b); err != nil {
+		return
+	}
+	var et EventTime
+	if b, err = msgp.ReadExtensionBytes(b, &et); err != nil {
+		return
+	}
+	record, _, err = msgp.ReadMapStrIntfBytes(b, nil)
+	return tag, et.Time, record, err
+}
+
+// jsonLine formats item as a JSON line of its record with the tag and time.
+func jsonLine(item []byte) ([]byte, error) {
+	tag, t, record, err := decode(item)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := record[TagField]; !ok {
+		record[TagField] = tag
+	}
+	if _, ok := record["time"]; !ok {
+		record["time"] = t.Format(time.RFC3339Nano)
+	}
+	b, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
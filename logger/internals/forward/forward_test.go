@@ -0,0 +1,271 @@
+package forward
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// fluentd is a forward protocol stand-in, recording the received records.
+type fluentd struct {
+	ln net.Listener
+
+	mu      sync.Mutex
+	tags    []string
+	records []map[string]interface{}
+}
+
+func newFluentd(t *testing.T, addr string) *fluentd {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fluentd{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(t, conn)
+		}
+	}()
+	return f
+}
+
+func (f *fluentd) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+
+	r := msgp.NewReader(conn)
+	for {
+		if _, err := r.ReadArrayHeader(); err != nil {
+			return
+		}
+		tag, err := r.ReadString()
+		if err != nil {
+			t.Errorf("cannot read tag: %v", err)
+			return
+		}
+		n, err := r.ReadArrayHeader()
+		if err != nil {
+			t.Errorf("cannot read entries: %v", err)
+			return
+		}
+		for i := uint32(0); i < n; i++ {
+			var et EventTime
+			r.ReadArrayHeader()
+			if err := r.ReadExtension(&et); err != nil {
+				t.Errorf("cannot read event time: %v", err)
+				return
+			}
+			record := map[string]interface{}{}
+			if err := r.ReadMapStrIntf(record); err != nil {
+				t.Errorf("cannot read record: %v", err)
+				return
+			}
+			f.mu.Lock()
+			f.tags = append(f.tags, tag)
+			f.records = append(f.records, record)
+			f.mu.Unlock()
+		}
+		options := map[string]interface{}{}
+		if err := r.ReadMapStrIntf(options); err != nil {
+			t.Errorf("cannot read options: %v", err)
+			return
+		}
+		if chunk, ok := options["chunk"].(string); ok {
+			ack := msgp.AppendMapHeader(nil, 1)
+			ack = msgp.AppendString(ack, "ack")
+			ack = msgp.AppendString(ack, chunk)
+			conn.Write(ack)
+		}
+	}
+}
+
+func (f *fluentd) messages() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var msgs []string
+	for _, r := range f.records {
+		msgs = append(msgs, r[MessageField].(string))
+	}
+	return msgs
+}
+
+// freeAddr returns a local address nothing listens on.
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func newTestHook(t *testing.T, addr string, config Config) *Hook {
+	host, port, _ := net.SplitHostPort(addr)
+	config.Host = host
+	config.Port, _ = strconv.Atoi(port)
+	config.Tag = "app"
+	config.MinBackoff = 5 * time.Millisecond
+	config.MaxBackoff = 20 * time.Millisecond
+	config.Timeout = time.Second
+
+	h, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return h
+}
+
+func newTestLogger(h *Hook) *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	l.AddHook(h)
+	return l
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestForward(t *testing.T) {
+	f := newFluentd(t, "127.0.0.1:0")
+	h := newTestHook(t, f.ln.Addr().String(), Config{RequireAck: true})
+	l := newTestLogger(h)
+
+	l.WithField("user", "bob").Info("first")
+	l.WithField("tag", "audit").Warn("second")
+	l.Info("third")
+
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.records) != 3 {
+		t.Fatalf("received %d records, want 3", len(f.records))
+	}
+	if f.tags[0] != "app" || f.tags[1] != "audit" || f.tags[2] != "app" {
+		t.Errorf("tags = %v, want app, audit, app", f.tags)
+	}
+	if r := f.records[0]; r[MessageField] != "first" || r[LevelField] != "info" || r["user"] != "bob" {
+		t.Errorf("record = %v", r)
+	}
+	if m := h.Metrics(); m.Sent != 3 || m.Buffered != 0 || m.Dropped != 0 {
+		t.Errorf("metrics = %+v, want 3 sent", m)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	addr := freeAddr(t)
+	h := newTestHook(t, addr, Config{})
+	defer h.Close(context.Background())
+	l := newTestLogger(h)
+
+	l.Info("first")
+	l.Info("second")
+	if m := h.Metrics(); m.Buffered != 2 || m.Connected {
+		t.Errorf("metrics = %+v, want 2 buffered while disconnected", m)
+	}
+	waitFor(t, func() bool { return h.Metrics().Failures > 0 })
+
+	f := newFluentd(t, addr)
+	waitFor(t, func() bool { return h.Metrics().Sent == 2 })
+
+	if msgs := f.messages(); strings.Join(msgs, ",") != "first,second" {
+		t.Errorf("received %v, want first, second", msgs)
+	}
+	if m := h.Metrics(); m.Reconnects != 1 || !m.Connected {
+		t.Errorf("metrics = %+v, want 1 reconnect", m)
+	}
+}
+
+func TestSpill(t *testing.T) {
+	addr := freeAddr(t)
+	path := filepath.Join(t.TempDir(), "spill")
+	h := newTestHook(t, addr, Config{BufferSize: 2, SpillPath: path})
+	l := newTestLogger(h)
+
+	for _, msg := range []string{"1", "2", "3", "4", "5"} {
+		l.Info(msg)
+	}
+	if m := h.Metrics(); m.Buffered != 2 || m.Spilled != 3 {
+		t.Errorf("metrics = %+v, want 2 buffered and 3 spilled", m)
+	}
+
+	// the spilled entries are kept on close and sent after a restart
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	h.Close(ctx)
+
+	f := newFluentd(t, addr)
+	h = newTestHook(t, addr, Config{BufferSize: 2, SpillPath: path})
+	waitFor(t, func() bool { return h.Metrics().Sent == 3 })
+	h.Close(context.Background())
+
+	if msgs := f.messages(); strings.Join(msgs, ",") != "3,4,5" {
+		t.Errorf("received %v, want the spilled entries", msgs)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	var out bytes.Buffer
+	h := newTestHook(t, freeAddr(t), Config{BufferSize: 1, Fallback: &out})
+	l := newTestLogger(h)
+
+	l.Info("buffered")
+	l.WithField("user", "bob").Warn("fallback")
+
+	if m := h.Metrics(); m.Buffered != 1 || m.Fallback != 1 {
+		t.Errorf("metrics = %+v, want 1 buffered and 1 fallback", m)
+	}
+	if !strings.Contains(out.String(), `"message":"fallback"`) || !strings.Contains(out.String(), `"user":"bob"`) {
+		t.Errorf("fallback = %s, want the entry as JSON", out.String())
+	}
+
+	// the buffered entry is written to the fallback when fluentd stays down
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	h.Close(ctx)
+
+	if m := h.Metrics(); m.Fallback != 2 || m.Dropped != 0 {
+		t.Errorf("metrics = %+v, want 2 fallback", m)
+	}
+	if !strings.Contains(out.String(), `"message":"buffered"`) {
+		t.Errorf("fallback = %s, want the buffered entry", out.String())
+	}
+}
+
+func TestDropped(t *testing.T) {
+	h := newTestHook(t, freeAddr(t), Config{BufferSize: 1})
+	l := newTestLogger(h)
+
+	l.Info("buffered")
+	l.Info("dropped")
+	if m := h.Metrics(); m.Dropped != 1 {
+		t.Errorf("metrics = %+v, want 1 dropped", m)
+	}
+	h.Close(context.Background())
+}
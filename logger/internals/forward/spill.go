@@ -0,0 +1,130 @@
+package forward
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// spill is a file of length prefixed items, read from the offset of the first unsent item.
+type spill struct {
+	f     *os.File
+	max   int64
+	read  int64 // offset of the first unsent item
+	size  int64
+	count int
+}
+
+// openSpill opens the spill file at path, counting the items left by a previous run.
+func openSpill(path string, max int64) (*spill, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spill{f: f, max: max}
+	var prefix [4]byte
+	for {
+		if _, err := f.ReadAt(prefix[:], s.size); err != nil {
+			if err == io.EOF {
+				break
+			}
+			f.Close()
+			return nil, err
+		}
+		next := s.size + 4 + int64(binary.BigEndian.Uint32(prefix[:]))
+		if fi, err := f.Stat(); err != nil || next > fi.Size() {
+			// a truncated item of an interrupted write
+			break
+		}
+		s.size = next
+		s.count++
+	}
+	if err := f.Truncate(s.size); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// push appends item, reporting false if the file would exceed its maximum size.
+func (s *spill) push(item []byte) (bool, error) {
+	if s.size+4+int64(len(item)) > s.max {
+		return false, nil
+	}
+
+	b := make([]byte, 4+len(item))
+	binary.BigEndian.PutUint32(b, uint32(len(item)))
+	copy(b[4:], item)
+	if _, err := s.f.WriteAt(b, s.size); err != nil {
+		return false, err
+	}
+	s.size += int64(len(b))
+	s.count++
+	return true, nil
+}
+
+// peek returns up to n unsent items, with the offset after each.
+func (s *spill) peek(n int) (items [][]byte, offsets []int64, err error) {
+	off := s.read
+	var prefix [4]byte
+	for len(items) < n && off < s.size {
+		if _, err := s.f.ReadAt(prefix[:], off); err != nil {
+			return nil, nil, err
+		}
+		item := make([]byte, binary.BigEndian.Uint32(prefix[:]))
+		if _, err := s.f.ReadAt(item, off+4); err != nil {
+			return nil, nil, err
+		}
+		off += 4 + int64(len(item))
+		items = append(items, item)
+		offsets = append(offsets, off)
+	}
+	if len(items) == 0 {
+		return nil, nil, fmt.Errorf("no items at offset %d", s.read)
+	}
+	return items, offsets, nil
+}
+
+// commit removes the n items before offset next, truncating the file once all are sent.
+func (s *spill) commit(n int, next int64) {
+	s.read = next
+	s.count -= n
+	if s.count <= 0 {
+		s.reset()
+	}
+}
+
+// reset empties the file.
+func (s *spill) reset() {
+	s.f.Truncate(0)
+	s.read, s.size, s.count = 0, 0, 0
+}
+
+// close closes the file, keeping the unsent items only.
+func (s *spill) close() error {
+	if s.read > 0 {
+		if err := s.compact(); err != nil {
+			s.f.Close()
+			return err
+		}
+	}
+	return s.f.Close()
+}
+
+// compact moves the unsent items to the start of the file.
+func (s *spill) compact() error {
+	rest := make([]byte, s.size-s.read)
+	if _, err := s.f.ReadAt(rest, s.read); err != nil {
+		return err
+	}
+	if _, err := s.f.WriteAt(rest, 0); err != nil {
+		return err
+	}
+	if err := s.f.Truncate(int64(len(rest))); err != nil {
+		return err
+	}
+	s.read, s.size = 0, int64(len(rest))
+	return nil
+}
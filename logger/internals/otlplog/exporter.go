@@ -0,0 +1,132 @@
+package otlplog
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Protocols of the exporter.
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+// Exporter sends export requests to a collector.
+type Exporter interface {
+	Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error
+	Shutdown(ctx context.Context) error
+}
+
+// NewExporter creates an exporter of the protocol. The endpoint is host:port for
+// gRPC, and a URL or host:port for HTTP, to which /v1/logs is added if it has no path.
+func NewExporter(protocol string, endpoint string, insecure bool, headers map[string]string) (Exporter, error) {
+	switch protocol {
+	case ProtocolGRPC, "":
+		return newGRPCExporter(endpoint, insecure, headers)
+	case ProtocolHTTP:
+		return newHTTPExporter(endpoint, insecure, headers), nil
+	}
+	return nil, fmt.Errorf("unknown otlp protocol %q", protocol)
+}
+
+type grpcExporter struct {
+	conn    *grpc.ClientConn
+	client  collogspb.LogsServiceClient
+	headers metadata.MD
+}
+
+func newGRPCExporter(endpoint string, insecureConn bool, headers map[string]string) (*grpcExporter, error) {
+	creds := credentials.NewTLS(&tls.Config{})
+	if insecureConn {
+		creds = insecure.NewCredentials()
+	}
+
+	// the connection is established lazily, so the collector may be down
+	conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial otlp endpoint %s: %v", endpoint, err)
+	}
+	return &grpcExporter{
+		conn:    conn,
+		client:  collogspb.NewLogsServiceClient(conn),
+		headers: metadata.New(headers),
+	}, nil
+}
+
+func (e *grpcExporter) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+	if len(e.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, e.headers)
+	}
+	_, err := e.client.Export(ctx, req)
+	return err
+}
+
+func (e *grpcExporter) Shutdown(ctx context.Context) error {
+	return e.conn.Close()
+}
+
+type httpExporter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newHTTPExporter(endpoint string, insecure bool, headers map[string]string) *httpExporter {
+	url := endpoint
+	if !strings.Contains(url, "://") {
+		if insecure {
+			url = "http://" + url
+		} else {
+			url = "https://" + url
+		}
+	}
+	if i := strings.Index(url, "://"); !strings.Contains(url[i+3:], "/") {
+		url += "/v1/logs"
+	}
+	return &httpExporter{url: url, headers: headers, client: &http.Client{}}
+}
+
+func (e *httpExporter) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("cannot encode otlp request: %v", err)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range e.headers {
+		r.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected otlp response %d: %s", resp.StatusCode, b)
+	}
+	return nil
+}
+
+func (e *httpExporter) Shutdown(ctx context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,100 @@
+package otlplog
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Formatter formats entries as OTLP JSON export requests, one per line, as read
+// by the file receivers of the OpenTelemetry collector.
+type Formatter struct {
+	Resource *resourcepb.Resource
+}
+
+// NewFormatter creates a formatter with the resource of the service.
+func NewFormatter(service string, version string, attrs map[string]string) *Formatter {
+	return &Formatter{Resource: NewResource(service, version, attrs)}
+}
+
+// OTLP JSON differs from the protobuf JSON mapping in the hex encoded IDs and the
+// numeric enums, so the request is written with these types.
+type (
+	jsonRequest struct {
+		ResourceLogs []jsonResourceLogs `json:"resourceLogs"`
+	}
+	jsonResourceLogs struct {
+		Resource  json.RawMessage `json:"resource"`
+		ScopeLogs []jsonScopeLogs `json:"scopeLogs"`
+	}
+	jsonScopeLogs struct {
+		Scope      json.RawMessage `json:"scope"`
+		LogRecords []jsonRecord    `json:"logRecords"`
+	}
+	jsonRecord struct {
+		TimeUnixNano         string            `json:"timeUnixNano"`
+		ObservedTimeUnixNano string            `json:"observedTimeUnixNano"`
+		SeverityNumber       int32             `json:"severityNumber"`
+		SeverityText         string            `json:"severityText"`
+		Body                 json.RawMessage   `json:"body"`
+		Attributes           []json.RawMessage `json:"attributes,omitempty"`
+		Flags                uint32            `json:"flags,omitempty"`
+		TraceID              string            `json:"traceId,omitempty"`
+		SpanID               string            `json:"spanId,omitempty"`
+	}
+)
+
+// Format implements logrus.Formatter.
+func (f *Formatter) Format(e *logrus.Entry) ([]byte, error) {
+	rl := newResourceLogs(f.Resource, []*logspb.LogRecord{NewRecord(e)})[0]
+	r := rl.ScopeLogs[0].LogRecords[0]
+
+	jr := jsonRecord{
+		TimeUnixNano:         strconv.FormatUint(r.TimeUnixNano, 10),
+		ObservedTimeUnixNano: strconv.FormatUint(r.ObservedTimeUnixNano, 10),
+		SeverityNumber:       int32(r.SeverityNumber),
+		SeverityText:         r.SeverityText,
+		Flags:                r.Flags,
+		TraceID:              hexID(r.TraceId),
+		SpanID:               hexID(r.SpanId),
+	}
+
+	var err error
+	if jr.Body, err = marshalJSON(r.Body); err != nil {
+		return nil, err
+	}
+	for _, a := range r.Attributes {
+		b, err := marshalJSON(a)
+		if err != nil {
+			return nil, err
+		}
+		jr.Attributes = append(jr.Attributes, b)
+	}
+
+	resource, err := marshalJSON(rl.Resource)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := marshalJSON(rl.ScopeLogs[0].Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(jsonRequest{ResourceLogs: []jsonResourceLogs{{
+		Resource:  resource,
+		ScopeLogs: []jsonScopeLogs{{Scope: scope, LogRecords: []jsonRecord{jr}}},
+	}}})
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func marshalJSON(m proto.Message) (json.RawMessage, error) {
+	return protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(m)
+}
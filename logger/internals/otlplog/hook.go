@@ -0,0 +1,225 @@
+package otlplog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// Hook defaults.
+const (
+	DefaultQueueSize     = 2048
+	DefaultBatchSize     = 512
+	DefaultBatchInterval = time.Second
+	DefaultTimeout       = 10 * time.Second
+)
+
+// HookConfig configures the batching of a hook.
+type HookConfig struct {
+	QueueSize     int           // records queued; more are dropped
+	BatchSize     int           // records per export
+	BatchInterval time.Duration // delay of a partial batch
+	Timeout       time.Duration // limit of an export
+	ErrorHandler  func(error)   // called with the export errors; defaults to printing to stderr
+}
+
+// Hook is a logrus hook exporting the entries as log records in batches from
+// a background worker.
+type Hook struct {
+	config   HookConfig
+	resource *resourcepb.Resource
+	exporter Exporter
+
+	queue   chan *logspb.LogRecord
+	flushes chan chan struct{}
+	done    chan struct{}
+
+	mu      sync.Mutex
+	closed  bool
+	dropped uint64
+}
+
+// NewHook creates a hook exporting with exporter the records of the resource.
+func NewHook(resource *resourcepb.Resource, exporter Exporter, config HookConfig) *Hook {
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.BatchInterval <= 0 {
+		config.BatchInterval = DefaultBatchInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(err error) {
+			fmt.Fprintf(os.Stderr, "otlplog: %v\n", err)
+		}
+	}
+
+	h := &Hook{
+		config:   config,
+		resource: resource,
+		exporter: exporter,
+		queue:    make(chan *logspb.LogRecord, config.QueueSize),
+		flushes:  make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// Levels implements logrus.Hook.
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook, queueing the record of e. It is dropped if the queue is full.
+func (h *Hook) Fire(e *logrus.Entry) error {
+	r := NewRecord(e)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil
+	}
+	select {
+	case h.queue <- r:
+	default:
+		h.dropped++
+	}
+	return nil
+}
+
+// Dropped returns the number of records dropped because the queue was full.
+func (h *Hook) Dropped() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.dropped
+}
+
+// Flush exports the queued records, waiting until they are exported or ctx is done.
+func (h *Hook) Flush(ctx context.Context) error {
+	exported := make(chan struct{})
+	select {
+	case h.flushes <- exported:
+	case <-h.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-exported:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close exports the queued records and shuts the exporter down, waiting until
+// they are exported or ctx is done.
+func (h *Hook) Close(ctx context.Context) error {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.queue)
+	}
+	h.mu.Unlock()
+
+	select {
+	case <-h.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return h.exporter.Shutdown(ctx)
+}
+
+func (h *Hook) run() {
+	defer close(h.done)
+
+	batch := make([]*logspb.LogRecord, 0, h.config.BatchSize)
+	timer := time.NewTimer(h.config.BatchInterval)
+	stopTimer(timer)
+
+	for {
+		select {
+		case r, ok := <-h.queue:
+			if !ok {
+				h.export(batch)
+				return
+			}
+			if len(batch) == 0 {
+				stopTimer(timer)
+				timer.Reset(h.config.BatchInterval)
+			}
+			batch = append(batch, r)
+			if len(batch) == h.config.BatchSize {
+				stopTimer(timer)
+				batch = h.export(batch)
+			}
+
+		case <-timer.C:
+			batch = h.export(batch)
+
+		case exported := <-h.flushes:
+			stopTimer(timer)
+			for drained := false; !drained; {
+				select {
+				case r, ok := <-h.queue:
+					if !ok {
+						drained = true
+						break
+					}
+					if batch = append(batch, r); len(batch) == h.config.BatchSize {
+						batch = h.export(batch)
+					}
+				default:
+					drained = true
+				}
+			}
+			batch = h.export(batch)
+			close(exported)
+		}
+	}
+}
+
+// stopTimer stops t and drains a tick that fired before, so that a Reset
+// does not see a stale tick.
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+// export exports batch, returning it emptied.
+func (h *Hook) export(batch []*logspb.LogRecord) []*logspb.LogRecord {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	records := make([]*logspb.LogRecord, len(batch))
+	copy(records, batch)
+	req := &collogspb.ExportLogsServiceRequest{ResourceLogs: newResourceLogs(h.resource, records)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.config.Timeout)
+	defer cancel()
+	if err := h.exporter.Export(ctx, req); err != nil {
+		h.config.ErrorHandler(fmt.Errorf("cannot export %d log records: %v", len(records), err))
+	}
+	return batch[:0]
+}
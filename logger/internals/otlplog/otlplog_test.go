@@ -0,0 +1,267 @@
+package otlplog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector is an in-process stand-in of the OpenTelemetry collector.
+type collector struct {
+	collogspb.UnimplementedLogsServiceServer
+
+	mu       sync.Mutex
+	requests []*collogspb.ExportLogsServiceRequest
+	headers  []string
+}
+
+func (c *collector) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.record(req, md.Get("authorization"))
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func (c *collector) record(req *collogspb.ExportLogsServiceRequest, headers []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, headers...)
+}
+
+func (c *collector) records() []*logspb.LogRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var records []*logspb.LogRecord
+	for _, req := range c.requests {
+		for _, rl := range req.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				records = append(records, sl.LogRecords...)
+			}
+		}
+	}
+	return records
+}
+
+func (c *collector) serveGRPC(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(s, c)
+	go s.Serve(ln)
+	t.Cleanup(s.Stop)
+	return ln.Addr().String()
+}
+
+func (c *collector) serveHTTP(t *testing.T) string {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		req := &collogspb.ExportLogsServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.record(req, r.Header.Values("Authorization"))
+	}))
+	t.Cleanup(s.Close)
+	return s.Listener.Addr().String()
+}
+
+var (
+	traceID, _ = trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _  = trace.SpanIDFromHex("00f067aa0ba902b7")
+)
+
+func spanCtx() context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+func TestNewRecord(t *testing.T) {
+	l := logrus.New()
+	e := l.WithContext(spanCtx()).WithFields(logrus.Fields{"user": "bob", "n": 3, "err": errors.New("failed")})
+	e.Level = logrus.WarnLevel
+	e.Message = "slow request"
+
+	r := NewRecord(e)
+	if r.SeverityNumber != logspb.SeverityNumber_SEVERITY_NUMBER_WARN || r.SeverityText != "warning" {
+		t.Errorf("severity = %v %q, want WARN", r.SeverityNumber, r.SeverityText)
+	}
+	if r.Body.GetStringValue() != "slow request" {
+		t.Errorf("body = %v", r.Body)
+	}
+	if !bytes.Equal(r.TraceId, traceID[:]) || !bytes.Equal(r.SpanId, spanID[:]) || r.Flags != 1 {
+		t.Errorf("trace = %x %x %d, want the span of the context", r.TraceId, r.SpanId, r.Flags)
+	}
+
+	attrs := map[string]interface{}{}
+	for _, a := range r.Attributes {
+		if s := a.Value.GetStringValue(); s != "" {
+			attrs[a.Key] = s
+		} else {
+			attrs[a.Key] = a.Value.GetIntValue()
+		}
+	}
+	if attrs["user"] != "bob" || attrs["n"] != int64(3) || attrs["err"] != "failed" {
+		t.Errorf("attributes = %v", attrs)
+	}
+}
+
+func TestNewRecordTraceFields(t *testing.T) {
+	e := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		FieldTrace:  traceID.String(),
+		FieldSpanID: spanID.String(),
+	})
+
+	r := NewRecord(e)
+	if !bytes.Equal(r.TraceId, traceID[:]) || !bytes.Equal(r.SpanId, spanID[:]) {
+		t.Errorf("trace = %x %x, want the trace fields", r.TraceId, r.SpanId)
+	}
+	if len(r.Attributes) != 0 {
+		t.Errorf("attributes = %v, want no trace fields", r.Attributes)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	want := map[logrus.Level]logspb.SeverityNumber{
+		logrus.TraceLevel: 1,
+		logrus.DebugLevel: 5,
+		logrus.InfoLevel:  9,
+		logrus.WarnLevel:  13,
+		logrus.ErrorLevel: 17,
+		logrus.FatalLevel: 21,
+		logrus.PanicLevel: 24,
+	}
+	for level, s := range want {
+		if got := Severity(level); got != s {
+			t.Errorf("Severity(%v) = %d, want %d", level, got, s)
+		}
+	}
+}
+
+func TestFormatter(t *testing.T) {
+	var out bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(&out)
+	l.SetFormatter(NewFormatter("api", "1.2.3", nil))
+	l.WithContext(spanCtx()).WithField("user", "bob").Error("cannot read")
+
+	var req struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string
+					Value struct{ StringValue string }
+				}
+			}
+			ScopeLogs []struct {
+				LogRecords []struct {
+					SeverityNumber int
+					SeverityText   string
+					Body           struct{ StringValue string }
+					TraceID        string `json:"traceId"`
+					SpanID         string `json:"spanId"`
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(out.Bytes(), &req); err != nil {
+		t.Fatalf("cannot decode %s: %v", out.String(), err)
+	}
+
+	rl := req.ResourceLogs[0]
+	if a := rl.Resource.Attributes; len(a) != 2 || a[0].Key != "service.name" || a[0].Value.StringValue != "api" || a[1].Value.StringValue != "1.2.3" {
+		t.Errorf("resource = %+v, want service name and version", a)
+	}
+	r := rl.ScopeLogs[0].LogRecords[0]
+	if r.SeverityNumber != 17 || r.Body.StringValue != "cannot read" || r.TraceID != traceID.String() || r.SpanID != spanID.String() {
+		t.Errorf("record = %+v", r)
+	}
+}
+
+func TestHookExport(t *testing.T) {
+	for _, protocol := range []string{ProtocolGRPC, ProtocolHTTP} {
+		t.Run(protocol, func(t *testing.T) {
+			c := &collector{}
+			endpoint := c.serveGRPC(t)
+			if protocol == ProtocolHTTP {
+				endpoint = c.serveHTTP(t)
+			}
+
+			exp, err := NewExporter(protocol, endpoint, true, map[string]string{"authorization": "Bearer token"})
+			if err != nil {
+				t.Fatalf("NewExporter() error = %v", err)
+			}
+			h := NewHook(NewResource("api", "1.2.3", map[string]string{"deployment.environment": "test"}), exp, HookConfig{
+				BatchSize:     2,
+				BatchInterval: time.Hour,
+			})
+
+			l := logrus.New()
+			l.SetOutput(io.Discard)
+			l.AddHook(h)
+			l.WithContext(spanCtx()).Info("first")
+			l.Info("second")
+			l.Warn("third")
+
+			if err := h.Close(context.Background()); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			records := c.records()
+			if len(records) != 3 || len(c.requests) != 2 {
+				t.Fatalf("exported %d records in %d requests, want 3 in 2", len(records), len(c.requests))
+			}
+			if records[0].Body.GetStringValue() != "first" || !bytes.Equal(records[0].TraceId, traceID[:]) {
+				t.Errorf("first record = %v", records[0])
+			}
+			if attrs := c.requests[0].ResourceLogs[0].Resource.Attributes; len(attrs) != 3 {
+				t.Errorf("resource attributes = %v, want 3", attrs)
+			}
+			if len(c.headers) != 2 || c.headers[0] != "Bearer token" {
+				t.Errorf("headers = %v, want the authorization header", c.headers)
+			}
+		})
+	}
+}
+
+func TestHookFlush(t *testing.T) {
+	c := &collector{}
+	exp, _ := NewExporter(ProtocolHTTP, c.serveHTTP(t), true, nil)
+	h := NewHook(NewResource("api", "", nil), exp, HookConfig{BatchInterval: time.Hour})
+	defer h.Close(context.Background())
+
+	h.Fire(logrus.NewEntry(logrus.New()).WithField("n", 1))
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if len(c.records()) != 1 {
+		t.Errorf("exported %d records, want 1", len(c.records()))
+	}
+}
@@ -0,0 +1,228 @@
+// Package otlplog converts logrus entries to OpenTelemetry log records, formatted as
+// OTLP JSON or exported over OTLP gRPC or HTTP.
+package otlplog
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// ScopeName is the instrumentation scope of the records.
+const ScopeName = "github.com/onmi-bv/commons/logger"
+
+// Trace fields, as set by logger.FromContext.
+const (
+	FieldTrace        = "trace"
+	FieldSpanID       = "spanId"
+	FieldTraceSampled = "traceSampled"
+)
+
+// severities maps the levels to severity numbers, as the OpenTelemetry logrus bridge does.
+var severities = map[logrus.Level]logspb.SeverityNumber{
+	logrus.TraceLevel: logspb.SeverityNumber_SEVERITY_NUMBER_TRACE,
+	logrus.DebugLevel: logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+	logrus.InfoLevel:  logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+	logrus.WarnLevel:  logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+	logrus.ErrorLevel: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	logrus.FatalLevel: logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+	logrus.PanicLevel: logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4,
+}
+
+// Severity returns the severity number of level.
+func Severity(level logrus.Level) logspb.SeverityNumber {
+	return severities[level]
+}
+
+// NewResource creates the resource of the records with the service name and
+// version and the extra attributes.
+func NewResource(service string, version string, attrs map[string]string) *resourcepb.Resource {
+	r := &resourcepb.Resource{}
+	if service != "" {
+		r.Attributes = append(r.Attributes, stringAttr("service.name", service))
+	}
+	if version != "" {
+		r.Attributes = append(r.Attributes, stringAttr("service.version", version))
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r.Attributes = append(r.Attributes, stringAttr(k, attrs[k]))
+	}
+	return r
+}
+
+// NewRecord converts e to a log record. The trace and span IDs are taken from the span
+// of the entry context, or else from the trace fields; the other fields are attributes.
+func NewRecord(e *logrus.Entry) *logspb.LogRecord {
+	now := time.Now()
+	t := e.Time
+	if t.IsZero() {
+		t = now
+	}
+
+	r := &logspb.LogRecord{
+		TimeUnixNano:         uint64(t.UnixNano()),
+		ObservedTimeUnixNano: uint64(now.UnixNano()),
+		SeverityNumber:       Severity(e.Level),
+		SeverityText:         e.Level.String(),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: e.Message}},
+	}
+
+	if sc := spanContext(e); sc.IsValid() {
+		traceID, spanID := sc.TraceID(), sc.SpanID()
+		r.TraceId, r.SpanId = traceID[:], spanID[:]
+		r.Flags = uint32(sc.TraceFlags())
+	}
+
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		if k != FieldTrace && k != FieldSpanID && k != FieldTraceSampled {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r.Attributes = append(r.Attributes, &commonpb.KeyValue{Key: k, Value: anyValue(e.Data[k])})
+	}
+
+	if e.HasCaller() {
+		r.Attributes = append(r.Attributes,
+			stringAttr("code.filepath", e.Caller.File),
+			&commonpb.KeyValue{Key: "code.lineno", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(e.Caller.Line)}}},
+			stringAttr("code.function", e.Caller.Function),
+		)
+	}
+	return r
+}
+
+// spanContext returns the span context of the entry context, or else of the trace fields.
+func spanContext(e *logrus.Entry) trace.SpanContext {
+	if e.Context != nil {
+		if sc := trace.SpanContextFromContext(e.Context); sc.IsValid() {
+			return sc
+		}
+	}
+
+	var config trace.SpanContextConfig
+	if s, ok := e.Data[FieldTrace].(string); ok {
+		config.TraceID, _ = trace.TraceIDFromHex(s)
+	}
+	if s, ok := e.Data[FieldSpanID].(string); ok {
+		config.SpanID, _ = trace.SpanIDFromHex(s)
+	}
+	if sampled, ok := e.Data[FieldTraceSampled].(bool); ok && sampled {
+		config.TraceFlags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(config)
+}
+
+func stringAttr(k string, v string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}}
+}
+
+// anyValue converts a field value, through JSON for other types than the OTLP ones.
+func anyValue(v interface{}) *commonpb.AnyValue {
+	switch v := v.(type) {
+	case nil:
+		return &commonpb.AnyValue{}
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	case int:
+		return intValue(int64(v))
+	case int8:
+		return intValue(int64(v))
+	case int16:
+		return intValue(int64(v))
+	case int32:
+		return intValue(int64(v))
+	case int64:
+		return intValue(v)
+	case uint:
+		return intValue(int64(v))
+	case uint8:
+		return intValue(int64(v))
+	case uint16:
+		return intValue(int64(v))
+	case uint32:
+		return intValue(int64(v))
+	case uint64:
+		return intValue(int64(v))
+	case float32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(v)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v}}
+	case time.Time:
+		return anyValue(v.Format(time.RFC3339Nano))
+	case error:
+		return anyValue(v.Error())
+	case fmt.Stringer:
+		return anyValue(v.String())
+	case []interface{}:
+		values := make([]*commonpb.AnyValue, len(v))
+		for i, e := range v {
+			values[i] = anyValue(e)
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		values := make([]*commonpb.KeyValue, len(keys))
+		for i, k := range keys {
+			values[i] = &commonpb.KeyValue{Key: k, Value: anyValue(v[k])}
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: values}}}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return anyValue(fmt.Sprint(v))
+	}
+	var j interface{}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return anyValue(fmt.Sprint(v))
+	}
+	return anyValue(j)
+}
+
+func intValue(v int64) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}
+}
+
+// newResourceLogs wraps records in the resource and the scope.
+func newResourceLogs(resource *resourcepb.Resource, records []*logspb.LogRecord) []*logspb.ResourceLogs {
+	return []*logspb.ResourceLogs{{
+		Resource: resource,
+		ScopeLogs: []*logspb.ScopeLogs{{
+			Scope:      &commonpb.InstrumentationScope{Name: ScopeName},
+			LogRecords: records,
+		}},
+	}}
+}
+
+// hexID encodes a trace or span ID as OTLP JSON does, empty for no ID.
+func hexID(id []byte) string {
+	if len(id) == 0 {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
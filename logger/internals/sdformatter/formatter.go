@@ -1,11 +1,13 @@
 package sdformatter
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-stack/stack"
@@ -26,6 +28,7 @@ const (
 )
 
 var levelsToSeverity = map[logrus.Level]severity{
+	logrus.TraceLevel: severityDebug,
 	logrus.DebugLevel: severityDebug,
 	logrus.InfoLevel:  severityInfo,
 	logrus.WarnLevel:  severityWarning,
@@ -45,15 +48,30 @@ type reportLocation struct {
 	FunctionName string `json:"function,omitempty"`
 }
 
-type context struct {
+// errorEventType marks an entry as an error of Error Reporting.
+const errorEventType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+
+type errorContext struct {
+	ReportLocation *errorLocation `json:"reportLocation,omitempty"`
+}
+
+type errorLocation struct {
+	FilePath     string `json:"filePath,omitempty"`
+	LineNumber   int    `json:"lineNumber,omitempty"`
+	FunctionName string `json:"functionName,omitempty"`
 }
 
 type entry struct {
+	Type           string                 `json:"@type,omitempty"`
 	Timestamp      string                 `json:"timestamp,omitempty"`
 	ServiceContext *serviceContext        `json:"serviceContext,omitempty"`
 	Message        string                 `json:"message,omitempty"`
 	Severity       severity               `json:"severity,omitempty"`
+	StackTrace     string                 `json:"stack_trace,omitempty"`
+	Context        *errorContext          `json:"context,omitempty"`
 	HTTPRequest    map[string]interface{} `json:"httpRequest,omitempty"`
+	InsertID       string                 `json:"logging.googleapis.com/insertId,omitempty"`
+	Labels         map[string]string      `json:"logging.googleapis.com/labels,omitempty"`
 	Trace          interface{}            `json:"logging.googleapis.com/trace,omitempty"`
 	SpanID         interface{}            `json:"logging.googleapis.com/spanId,omitempty"`
 	TraceSampled   interface{}            `json:"logging.googleapis.com/trace_sampled,omitempty"`
@@ -67,6 +85,15 @@ type Formatter struct {
 	Service   string
 	Version   string
 	StackSkip []string
+
+	// ProjectID is the project of the traces. Defaults to the GOOGLE_CLOUD_PROJECT env.
+	ProjectID string
+
+	// LabelPrefix selects the fields sent as labels, without the prefix. Empty disables labels.
+	LabelPrefix string
+
+	insertPrefix string
+	insertSeq    uint64
 }
 
 // Option lets you configure the Formatter.
@@ -93,12 +120,28 @@ func WithStackSkip(v string) Option {
 	}
 }
 
+// WithProjectID lets you configure the project of the traces.
+func WithProjectID(id string) Option {
+	return func(f *Formatter) {
+		f.ProjectID = id
+	}
+}
+
+// WithLabelPrefix lets you configure the prefix of the fields sent as labels, i.e. "label.".
+func WithLabelPrefix(p string) Option {
+	return func(f *Formatter) {
+		f.LabelPrefix = p
+	}
+}
+
 // NewFormatter returns a new Formatter.
 func NewFormatter(options ...Option) *Formatter {
 	fmtr := Formatter{
 		StackSkip: []string{
 			"github.com/sirupsen/logrus",
 		},
+		ProjectID:    os.Getenv("GOOGLE_CLOUD_PROJECT"),
+		insertPrefix: newInsertPrefix(),
 	}
 	for _, option := range options {
 		option(&fmtr)
@@ -106,7 +149,16 @@ func NewFormatter(options ...Option) *Formatter {
 	return &fmtr
 }
 
-func (f *Formatter) errorOrigin() (stack.Call, error) {
+// newInsertPrefix returns a random prefix of the insert IDs, unique per formatter.
+func newInsertPrefix() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// errorStack returns the call stack from the origin of the error, skipping
+// the packages of StackSkip.
+func (f *Formatter) errorStack() []stack.Call {
 	skip := func(pkg string) bool {
 		for _, skip := range f.StackSkip {
 			if pkg == skip {
@@ -116,21 +168,42 @@ func (f *Formatter) errorOrigin() (stack.Call, error) {
 		return false
 	}
 
+	var calls []stack.Call
 	// We start at 2 to skip this call and our caller's call.
 	for i := 2; ; i++ {
 		c := stack.Caller(i)
 		// ErrNoFunc indicates we're over traversing the stack.
 		if _, err := c.MarshalText(); err != nil {
-			return stack.Call{}, nil
+			return calls
 		}
-		pkg := fmt.Sprintf("%+k", c)
-		// Remove vendoring from package path.
-		parts := strings.SplitN(pkg, "/vendor/", 2)
-		pkg = parts[len(parts)-1]
-		if !skip(pkg) {
-			return c, nil
+		if len(calls) == 0 {
+			pkg := fmt.Sprintf("%+k", c)
+			// Remove vendoring from package path.
+			parts := strings.SplitN(pkg, "/vendor/", 2)
+			pkg = parts[len(parts)-1]
+			if skip(pkg) {
+				continue
+			}
 		}
+		calls = append(calls, c)
+	}
+}
+
+// stackTrace formats the calls as a go stack trace after the message, as
+// parsed by Error Reporting.
+func stackTrace(message string, calls []stack.Call) string {
+	var b strings.Builder
+	b.WriteString(message)
+	b.WriteString("\n\ngoroutine 1 [running]:\n")
+	for _, c := range calls {
+		fmt.Fprintf(&b, "%+n()\n\t%#s:%d\n", c, c, c)
 	}
+	return b.String()
+}
+
+// isError reports whether entries of level are reported as errors.
+func isError(level logrus.Level) bool {
+	return level <= logrus.ErrorLevel
 }
 
 // Format formats a logrus entry according to the Stackdriver specifications.
@@ -138,14 +211,24 @@ func (f *Formatter) errorOrigin() (stack.Call, error) {
 func (f *Formatter) Format(e *logrus.Entry) ([]byte, error) {
 	severity := levelsToSeverity[e.Level]
 
+	data := make(map[string]interface{}, len(e.Data))
+	for k, v := range e.Data {
+		data[k] = v
+	}
+
 	ee := entry{
 		Message:  e.Message,
 		Severity: severity,
-		Data:     e.Data,
+		Data:     data,
+		InsertID: fmt.Sprintf("%s-%d", f.insertPrefix, atomic.AddUint64(&f.insertSeq, 1)),
 	}
 
 	if !skipTimestamp {
-		ee.Timestamp = time.Now().UTC().Format(time.RFC3339)
+		t := e.Time
+		if t.IsZero() {
+			t = time.Now()
+		}
+		ee.Timestamp = t.UTC().Format(time.RFC3339Nano)
 	}
 
 	ee.ServiceContext = &serviceContext{
@@ -163,14 +246,35 @@ func (f *Formatter) Format(e *logrus.Entry) ([]byte, error) {
 		ee.Message = e.Message
 	}
 
-	// Extract report location from call stack.
-	if c, err := f.errorOrigin(); err == nil {
-		lineNumber, _ := strconv.ParseInt(fmt.Sprintf("%d", c), 10, 64)
+	// Extract report location and stack trace of errors from call stack.
+	if isError(e.Level) {
+		if calls := f.errorStack(); len(calls) > 0 {
+			c := calls[0]
+			ee.ReportLocation = &reportLocation{
+				FilePath:     fmt.Sprintf("%+s", c),
+				LineNumber:   c.Frame().Line,
+				FunctionName: fmt.Sprintf("%n", c),
+			}
+			ee.Type = errorEventType
+			ee.StackTrace = stackTrace(ee.Message, calls)
+			ee.Context = &errorContext{ReportLocation: &errorLocation{
+				FilePath:     ee.ReportLocation.FilePath,
+				LineNumber:   ee.ReportLocation.LineNumber,
+				FunctionName: ee.ReportLocation.FunctionName,
+			}}
+		}
+	}
 
-		ee.ReportLocation = &reportLocation{
-			FilePath:     fmt.Sprintf("%+s", c),
-			LineNumber:   int(lineNumber),
-			FunctionName: fmt.Sprintf("%n", c),
+	// Fields with the label prefix are sent as labels.
+	if f.LabelPrefix != "" {
+		for k, v := range ee.Data {
+			if strings.HasPrefix(k, f.LabelPrefix) {
+				if ee.Labels == nil {
+					ee.Labels = map[string]string{}
+				}
+				ee.Labels[strings.TrimPrefix(k, f.LabelPrefix)] = fmt.Sprint(v)
+				delete(ee.Data, k)
+			}
 		}
 	}
 
@@ -183,7 +287,11 @@ func (f *Formatter) Format(e *logrus.Entry) ([]byte, error) {
 		}
 	}
 	if data, ok := ee.Data["trace"]; ok {
-		ee.Trace = fmt.Sprintf("projects/%s/traces/%s", os.Getenv("GOOGLE_CLOUD_PROJECT"), data)
+		if s, ok := data.(string); ok && strings.HasPrefix(s, "projects/") {
+			ee.Trace = s
+		} else {
+			ee.Trace = fmt.Sprintf("projects/%s/traces/%s", f.ProjectID, data)
+		}
 		delete(ee.Data, "trace")
 	}
 	if data, ok := ee.Data["spanId"]; ok {
@@ -0,0 +1,138 @@
+package sdformatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func format(t *testing.T, f *Formatter, e *logrus.Entry) map[string]interface{} {
+	b, err := f.Format(e)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("cannot decode %s: %v", b, err)
+	}
+	return m
+}
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		level logrus.Level
+		want  string
+	}{
+		{logrus.TraceLevel, "DEBUG"},
+		{logrus.DebugLevel, "DEBUG"},
+		{logrus.InfoLevel, "INFO"},
+		{logrus.WarnLevel, "WARNING"},
+		{logrus.ErrorLevel, "ERROR"},
+		{logrus.FatalLevel, "CRITICAL"},
+		{logrus.PanicLevel, "ALERT"},
+	}
+	f := NewFormatter()
+	for _, tt := range tests {
+		m := format(t, f, &logrus.Entry{Level: tt.level, Message: "msg", Data: logrus.Fields{}})
+		if m["severity"] != tt.want {
+			t.Errorf("severity of %v = %v, want %v", tt.level, m["severity"], tt.want)
+		}
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 123456789, time.FixedZone("CET", 3600))
+	m := format(t, NewFormatter(), &logrus.Entry{Time: ts, Level: logrus.InfoLevel, Data: logrus.Fields{}})
+	if want := "2020-01-02T02:04:05.123456789Z"; m["timestamp"] != want {
+		t.Errorf("timestamp = %v, want %v", m["timestamp"], want)
+	}
+}
+
+func TestTraceAndLabels(t *testing.T) {
+	f := NewFormatter(WithProjectID("project"), WithLabelPrefix("label."))
+	e := &logrus.Entry{Level: logrus.InfoLevel, Message: "msg", Data: logrus.Fields{
+		"trace":       "abc",
+		"spanId":      "1",
+		"label.user":  "bob",
+		"label.count": 3,
+		"other":       "x",
+	}}
+	m := format(t, f, e)
+
+	if want := "projects/project/traces/abc"; m["logging.googleapis.com/trace"] != want {
+		t.Errorf("trace = %v, want %v", m["logging.googleapis.com/trace"], want)
+	}
+	labels, _ := m["logging.googleapis.com/labels"].(map[string]interface{})
+	if labels["user"] != "bob" || labels["count"] != "3" {
+		t.Errorf("labels = %v, want user and count", labels)
+	}
+	data, _ := m["data"].(map[string]interface{})
+	if len(data) != 1 || data["other"] != "x" {
+		t.Errorf("data = %v, want only other", data)
+	}
+	if len(e.Data) != 5 {
+		t.Errorf("entry fields were modified: %v", e.Data)
+	}
+
+	e.Data = logrus.Fields{"trace": "projects/other/traces/abc"}
+	if m := format(t, f, e); m["logging.googleapis.com/trace"] != "projects/other/traces/abc" {
+		t.Errorf("trace = %v, want it unchanged", m["logging.googleapis.com/trace"])
+	}
+}
+
+func TestInsertID(t *testing.T) {
+	f := NewFormatter()
+	seen := map[interface{}]bool{}
+	for i := 0; i < 10; i++ {
+		id := format(t, f, &logrus.Entry{Level: logrus.InfoLevel, Data: logrus.Fields{}})["logging.googleapis.com/insertId"]
+		if id == nil || id == "" || seen[id] {
+			t.Fatalf("insertId = %v, want a unique id", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestErrorEvent(t *testing.T) {
+	f := NewFormatter()
+
+	m := format(t, f, &logrus.Entry{Level: logrus.InfoLevel, Message: "msg", Data: logrus.Fields{}})
+	for _, k := range []string{"@type", "stack_trace", "context", "logging.googleapis.com/sourceLocation"} {
+		if _, ok := m[k]; ok {
+			t.Errorf("info entry has %s", k)
+		}
+	}
+
+	var out bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(&out)
+	l.SetFormatter(f)
+	l.WithError(errors.New("connection refused")).Error("cannot query")
+	m = nil
+	if err := json.Unmarshal(out.Bytes(), &m); err != nil {
+		t.Fatalf("cannot decode %s: %v", out.Bytes(), err)
+	}
+	if m["@type"] != errorEventType {
+		t.Errorf("@type = %v, want %v", m["@type"], errorEventType)
+	}
+	if m["message"] != "cannot query: connection refused" {
+		t.Errorf("message = %v, want the error appended", m["message"])
+	}
+	st, _ := m["stack_trace"].(string)
+	if !strings.HasPrefix(st, "cannot query: connection refused\n\ngoroutine 1 [running]:\n") || !strings.Contains(st, "TestErrorEvent()\n\t") {
+		t.Errorf("stack_trace = %q, want a go stack trace from the test", st)
+	}
+	loc, _ := m["logging.googleapis.com/sourceLocation"].(map[string]interface{})
+	if !strings.HasSuffix(loc["file"].(string), "formatter_test.go") || loc["function"] != "TestErrorEvent" {
+		t.Errorf("sourceLocation = %v, want the test", loc)
+	}
+	ctx, _ := m["context"].(map[string]interface{})
+	report, _ := ctx["reportLocation"].(map[string]interface{})
+	if report["functionName"] != "TestErrorEvent" || report["lineNumber"] == nil {
+		t.Errorf("context.reportLocation = %v, want the test", report)
+	}
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/onmi-bv/commons/internal/alerting"
 	"github.com/onmi-bv/commons/internal/slackrus"
 
-	"github.com/evalphobia/logrus_fluent"
 	"github.com/onmi-bv/commons/confighelper"
+	"github.com/onmi-bv/commons/logger/internals/forward"
+	"github.com/onmi-bv/commons/logger/internals/otlplog"
 	stackdriver "github.com/onmi-bv/commons/logger/internals/sdformatter"
 	logger "github.com/sirupsen/logrus"
 )
@@ -18,80 +24,319 @@ import (
 // Logger defines application configuration
 type Logger struct {
 	// Output sets the output destination for the logger. I.e., stderr, stdout, discard
-	Output string `mapstructure:"OUTPUT"`
+	Output string `mapstructure:"OUTPUT" validate:"oneof=stderr stdout discard"`
 
 	// Level sets the log level. I.e., trace, debug, info, warning, error
-	Level string `mapstructure:"LEVEL"`
+	Level string `mapstructure:"LEVEL" default:"info" validate:"oneof=trace debug info warn warning error fatal panic"`
 
-	// Formatter sets the output formatter type. I.e., opts: text (plain text), json, sd (stackdriver format)
-	Formatter string `mapstructure:"FORMATTER"`
+	// Formatter sets the output formatter type. I.e., opts: text (plain text), json, sd (stackdriver format), otlp (OTLP JSON log records)
+	Formatter string `mapstructure:"FORMATTER" default:"text" validate:"oneof=text json sd otlp"`
 
-	// External sets external logging. Enable to use fluentd
-	External bool `mapstructure:"EXTERNAL"`
+	// SDProjectID sets the project of the traces of the sd formatter. Defaults to the GOOGLE_CLOUD_PROJECT env.
+	SDProjectID string `mapstructure:"SD_PROJECT_ID"`
+
+	// SDLabelPrefix sets the prefix of the fields sent as labels by the sd formatter, i.e. "label.". Empty disables labels.
+	SDLabelPrefix string `mapstructure:"SD_LABEL_PREFIX"`
+
+	// External enables external logging to the output set by ExternalOutput
+	External bool `mapstructure:"EXTERNAL" default:"false"`
+
+	// ExternalOutput sets the external output. I.e., fluentd (fluentd forward protocol), otlp (OpenTelemetry collector)
+	ExternalOutput string `mapstructure:"EXTERNAL_OUTPUT" default:"fluentd" validate:"oneof=fluentd otlp"`
 
 	// FluentdHost sets the fluentd host
-	FluentdHost string `mapstructure:"FLUENTD_HOST"`
+	FluentdHost string `mapstructure:"FLUENTD_HOST" default:"127.0.0.1"`
 
 	// FluentdPort sets the fluentd port
-	FluentdPort int `mapstructure:"FLUENTD_PORT"`
+	FluentdPort int `mapstructure:"FLUENTD_PORT" default:"24224" validate:"min=1,max=65535"`
+
+	// FluentdTag sets the fluentd tag, overridden by the tag field of an entry. Defaults to the message.
+	FluentdTag string `mapstructure:"FLUENTD_TAG"`
+
+	// FluentdBufferSize sets the number of entries buffered in memory while fluentd is unreachable
+	FluentdBufferSize int `mapstructure:"FLUENTD_BUFFER_SIZE" default:"10000" validate:"min=1"`
+
+	// FluentdSpillPath sets the file buffering the entries beyond the buffer size. Empty disables it
+	FluentdSpillPath string `mapstructure:"FLUENTD_SPILL_PATH"`
+
+	// FluentdSpillMaxBytes limits the size of the spill file
+	FluentdSpillMaxBytes int64 `mapstructure:"FLUENTD_SPILL_MAX_BYTES" default:"67108864" validate:"min=1"`
+
+	// FluentdMaxBackoff sets the maximum delay between reconnects to fluentd
+	FluentdMaxBackoff time.Duration `mapstructure:"FLUENTD_MAX_BACKOFF" default:"30s" validate:"min=1ms"`
+
+	// FluentdRequireAck waits for fluentd to acknowledge the sent entries, resending the others
+	FluentdRequireAck bool `mapstructure:"FLUENTD_REQUIRE_ACK" default:"false"`
+
+	// FluentdFallback sets the output of the entries which cannot be buffered. I.e., stdout, stderr, discard
+	FluentdFallback string `mapstructure:"FLUENTD_FALLBACK" default:"stdout" validate:"oneof=stdout stderr discard"`
 
 	// FieldMap (json) allows users to customize the names of keys for default fields.
 	// FieldKeyTime:  "@timestamp"
 	// FieldKeyLevel: "@level"
 	// FieldKeyMsg:   "@message"
-	FieldMap string `mapstructure:"FIELD_MAP"`
+	FieldMap string `mapstructure:"FIELD_MAP" default:""`
 
 	// PrettyPrint will indent all json logs
-	PrettyPrint bool `mapstructure:"PRETTY_PRINT"`
+	PrettyPrint bool `mapstructure:"PRETTY_PRINT" default:"false"`
 
 	// SetReporterCaller enables logging the report caller
-	SetReporterCaller bool `mapstructure:"SET_REPORTER_CALLER"`
+	SetReporterCaller bool `mapstructure:"SET_REPORTER_CALLER" default:"false"`
+
+	// Slack configures the slack integration, sent as the slack notifier of the alerting hook.
+	// It is ignored if ALERTING.SLACK is enabled. HOOK_URL is required unless DISABLED is set.
+	Slack slackrus.Hook `mapstructure:"SLACK"`
 
-	// Slack configures slack integration
-	Slack slackrus.Hook
+	// Alerting configures the alert notifiers: slack, a JSON webhook, Teams and email
+	Alerting alerting.Config `mapstructure:"ALERTING"`
+
+	// Sampling limits repeated log entries
+	Sampling Sampling `mapstructure:"SAMPLING"`
+
+	// OTLP configures the OpenTelemetry log records and their export
+	OTLP OTLP `mapstructure:"OTLP"`
 
 	// logger
 	*logger.Logger
+
+	// state holds the hooks of the initialized logger
+	state *state
+}
+
+// state holds the hooks and formatter settings of an initialized logger. It is shared
+// by the copies of the config, so runtime changes and Close apply to all of them.
+type state struct {
+	mu          sync.Mutex
+	logger      *logger.Logger
+	slackHook   *slackrus.Hook
+	alertHook   *alerting.Hook
+	forwardHook *forward.Hook
+	otlpHook    *otlplog.Hook
+	sampler     *sampler
+	watcher     *confighelper.Watcher[Logger]
+	appName     string
+	appVersion  string
 }
 
 // NewLogger creates a config struct with log default values
 func NewLogger() Logger {
-	return Logger{
-		Level:             "info",
-		External:          false,
-		FluentdHost:       "127.0.0.1",
-		FluentdPort:       24224,
-		FieldMap:          "",
-		PrettyPrint:       false,
-		SetReporterCaller: false,
-		Formatter:         "text",
-		Slack:             slackrus.NewHook(),
+	l := Logger{
+		Slack: slackrus.NewHook(),
 	}
+	confighelper.SetDefaults(&l)
+	return l
 }
 
 // Configuration used for initialization
 type Configuration struct {
-	AppName string
-	Version string
-	Path    string // Path to config file.
-	Prefix  string // Prefix to environment variables.
+	AppName  string
+	Version  string
+	Path     string                // Path to config file.
+	Prefix   string                // Prefix to environment variables.
+	Secrets  confighelper.Resolver // Resolves secret:// references, i.e. for the slack webhook URL.
+	Sources  []confighelper.Option // Layered sources, i.e. confighelper.WithDotEnv, applied after Path and Prefix.
+	Watch    bool                  // Watch the config file and apply changes of LEVEL, FORMATTER and SLACK.DISABLED at runtime.
+	Standard bool                  // Configure the logrus standard logger, and the entries of FromContext, instead of a dedicated logger.
 }
 
-// Init ...
+// Init loads and initializes a logger. Runtime changes of a watched config file
+// apply to the returned Logger and its copies.
 func Init(ctx context.Context, conf Configuration) (Logger, error) {
 	mLogger := NewLogger()
 
-	err := confighelper.ReadConfig(conf.Path, conf.Prefix, &mLogger)
+	l := confighelper.New(confighelper.Options(conf.Path, conf.Prefix, conf.Secrets, conf.Sources...)...)
+	err := l.LoadContext(ctx, &mLogger)
 	if err != nil {
 		return mLogger, err
 	}
 
-	mLogger.Logger, err = mLogger.Initialize(ctx, conf.AppName, conf.Version)
+	if conf.Standard {
+		_, err = mLogger.InitializeStandard(ctx, conf.AppName, conf.Version)
+	} else {
+		_, err = mLogger.Initialize(ctx, conf.AppName, conf.Version)
+	}
+	if err != nil || !conf.Watch {
+		return mLogger, err
+	}
+
+	err = mLogger.watch(l)
 
 	return mLogger, err
 }
 
-// LoadAndInitialize loads configuration from file or environment and initializes.
+// watch subscribes to changes of the config file loaded by l.
+func (config *Logger) watch(l *confighelper.Loader) error {
+	fresh := func() Logger {
+		c := NewLogger()
+		c.Slack.Username = config.Slack.Username // defaults to the app name
+		return c
+	}
+
+	w, err := confighelper.Watch(l, *config, fresh)
+	if err != nil {
+		return fmt.Errorf("cannot watch log config: %v", err)
+	}
+
+	s := config.state
+	w.OnError(func(err error) {
+		s.logger.Errorf("cannot reload log config: %v", err)
+	})
+	w.Subscribe(s.apply)
+
+	// the watcher is closed outside of the lock, as it waits for a running apply
+	s.mu.Lock()
+	old := s.watcher
+	s.watcher = w
+	s.mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+
+	return nil
+}
+
+// apply updates the log level, formatter and slack hook on a config change.
+func (s *state) apply(c confighelper.Change[Logger]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c.Has("LEVEL") {
+		logLevel, err := logger.ParseLevel(c.New.Level)
+		if err != nil {
+			s.logger.Errorf("cannot apply log level: %v", err)
+		} else {
+			s.logger.SetLevel(logLevel)
+		}
+	}
+
+	if c.Has("FORMATTER") || c.Has("FIELD_MAP") || c.Has("PRETTY_PRINT") {
+		f, err := c.New.formatter(s.appName, s.appVersion)
+		if err != nil {
+			s.logger.Errorf("cannot apply log formatter: %v", err)
+		} else if f != nil {
+			s.setFormatter(f)
+		}
+	}
+
+	if c.Has("SLACK.DISABLED") && s.slackHook != nil {
+		hook := *s.slackHook
+		hook.Disabled = c.New.Slack.Disabled
+		s.alertHook.Replace("slack", &hook)
+		s.slackHook = &hook
+	}
+
+	s.logger.Infof("applied log config change: %v", c.Keys)
+}
+
+// replaceHook replaces the old hook of the logger by new.
+func (s *state) replaceHook(old logger.Hook, new logger.Hook) {
+	if s.sampler != nil {
+		s.sampler.replaceHook(old, new)
+		return
+	}
+	s.logger.ReplaceHooks(replaceHook(s.logger.Hooks, old, new))
+}
+
+// replaceHook returns the hooks with old replaced by new.
+func replaceHook(current logger.LevelHooks, old logger.Hook, new logger.Hook) logger.LevelHooks {
+	hooks := make(logger.LevelHooks)
+	for level, hs := range current {
+		for _, h := range hs {
+			if h != old {
+				hooks[level] = append(hooks[level], h)
+			}
+		}
+	}
+	hooks.Add(new)
+	return hooks
+}
+
+// setFormatter sets the formatter of the logger, dropping the entries suppressed by sampling.
+func (s *state) setFormatter(f logger.Formatter) {
+	if s.sampler != nil {
+		f = &samplingFormatter{Formatter: f, sampler: s.sampler}
+	}
+	s.logger.SetFormatter(f)
+}
+
+// ForwardMetrics counts the entries of the fluentd output.
+type ForwardMetrics = forward.Metrics
+
+// ForwardMetrics returns the counts of the sent, buffered, spilled and dropped fluentd
+// entries, or false if the fluentd output is disabled.
+func (config *Logger) ForwardMetrics() (ForwardMetrics, bool) {
+	if config.state == nil {
+		return ForwardMetrics{}, false
+	}
+	config.state.mu.Lock()
+	h := config.state.forwardHook
+	config.state.mu.Unlock()
+
+	if h == nil {
+		return ForwardMetrics{}, false
+	}
+	return h.Metrics(), true
+}
+
+// SampleCounts returns the number of sampled and suppressed entries per level,
+// or nil maps if sampling is disabled.
+func (config *Logger) SampleCounts() (sampled map[logger.Level]uint64, suppressed map[logger.Level]uint64) {
+	if config.state == nil {
+		return nil, nil
+	}
+	config.state.mu.Lock()
+	s := config.state.sampler
+	config.state.mu.Unlock()
+
+	if s == nil {
+		return nil, nil
+	}
+	return s.Counts()
+}
+
+// Close stops watching the config file and the background work of the logger, logging the last
+// sampling summary and sending the queued fluentd entries, log records, alerts and slack messages
+// until ctx is done.
+func (config *Logger) Close(ctx context.Context) error {
+	s := config.state
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	w := s.watcher
+	s.watcher = nil
+	s.mu.Unlock()
+	if w != nil {
+		w.Close()
+	}
+
+	s.mu.Lock()
+	if s.sampler != nil {
+		s.sampler.close()
+	}
+
+	var hooks []closer
+	if s.forwardHook != nil {
+		hooks = append(hooks, s.forwardHook)
+	}
+	if s.otlpHook != nil {
+		hooks = append(hooks, s.otlpHook)
+	}
+	if s.alertHook != nil {
+		hooks = append(hooks, s.alertHook)
+	}
+	s.mu.Unlock()
+
+	var err error
+	for _, h := range hooks {
+		if herr := h.Close(ctx); herr != nil && err == nil {
+			err = herr
+		}
+	}
+	return err
+}
+
+// LoadAndInitialize loads configuration from file or environment and initializes a dedicated logger.
 func LoadAndInitialize(ctx context.Context, cFile string, prefix string, appName string, version string) (mConfig Logger, mLogger *logger.Logger, err error) {
 	mConfig = NewLogger()
 
@@ -104,8 +349,37 @@ func LoadAndInitialize(ctx context.Context, cFile string, prefix string, appName
 	return
 }
 
-// Initialize implements logic for application log configuration
+// Initialize builds a dedicated logger from the configuration, i.e. for a library.
+// Neither the logrus standard logger nor the entries of FromContext are changed;
+// use InitializeStandard to configure them.
 func (config *Logger) Initialize(ctx context.Context, appName string, appVersion string) (*logger.Logger, error) {
+	return config.initialize(logger.New(), appName, appVersion)
+}
+
+// InitializeStandard configures the logrus standard logger, used by the package level
+// functions of logrus, and the entries of FromContext without a logger in the context.
+// It can be called repeatedly: the hooks added by a previous call are replaced.
+func (config *Logger) InitializeStandard(ctx context.Context, appName string, appVersion string) (*logger.Logger, error) {
+	l, err := config.initialize(logger.StandardLogger(), appName, appVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// * set the logger and app fields of FromContext
+	fields := logger.Fields{}
+	if appName != "" {
+		fields[FieldApp] = appName
+	}
+	if appVersion != "" {
+		fields[FieldVersion] = appVersion
+	}
+	setBase(l.WithFields(fields))
+
+	return l, nil
+}
+
+// initialize configures l and sets it as the logger of the config.
+func (config *Logger) initialize(l *logger.Logger, appName string, appVersion string) (_ *logger.Logger, err error) {
 
 	// * set log level
 	logLevel, err := logger.ParseLevel(config.Level)
@@ -113,69 +387,265 @@ func (config *Logger) Initialize(ctx context.Context, appName string, appVersion
 		return nil, fmt.Errorf("parse error: %v", err)
 	}
 
-	logger.SetLevel(logLevel)
-	logger.WithContext(ctx)
+	// * create log formatter
+	formatter, err := config.formatter(appName, appVersion)
+	if err != nil {
+		return nil, err
+	}
 
-	if appName != "" {
-		logger.WithField("app", appName)
+	// * create hooks, closing the started ones on errors
+	var hooks []logger.Hook
+	var started []closer
+	defer func() {
+		if err != nil {
+			closeHooks(started)
+		}
+	}()
+
+	// log external
+	var forwardHook *forward.Hook
+	var otlpHook *otlplog.Hook
+	output := strings.ToLower(config.ExternalOutput)
+	switch {
+	case !config.External:
+	case output == "" || output == "fluentd":
+		var fallback io.Writer
+		switch config.FluentdFallback {
+		case "stdout":
+			fallback = os.Stdout
+		case "stderr":
+			fallback = os.Stderr
+		}
+		hook, err := forward.New(forward.Config{
+			Host:          config.FluentdHost,
+			Port:          config.FluentdPort,
+			Tag:           config.FluentdTag,
+			BufferSize:    config.FluentdBufferSize,
+			SpillPath:     config.FluentdSpillPath,
+			SpillMaxBytes: config.FluentdSpillMaxBytes,
+			MaxBackoff:    config.FluentdMaxBackoff,
+			RequireAck:    config.FluentdRequireAck,
+			Fallback:      fallback,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot configure fluentd output: %v", err)
+		}
+		forwardHook = hook
+		hooks = append(hooks, hook)
+		started = append(started, hook)
+
+	case output == "otlp":
+		hook, err := config.OTLP.newHook(appName, appVersion)
+		if err != nil {
+			return nil, fmt.Errorf("cannot configure otlp output: %v", err)
+		}
+		otlpHook = hook
+		hooks = append(hooks, hook)
+		started = append(started, hook)
 	}
 
+	//  add slack, as the slack notifier of the alerting hook unless ALERTING.SLACK is enabled
+	if config.Slack.Username == "" {
+		config.Slack.Username = appName
+	}
+	var slackHook *slackrus.Hook
+	slackIgnored := false
+	switch {
+	case config.Slack.Disabled && config.Slack.HookURL == "":
+	case config.Alerting.Slack.Enabled:
+		slackIgnored = !config.Slack.Disabled
+	case config.Slack.HookURL == "":
+		return nil, fmt.Errorf("cannot configure slack: SLACK.HOOK_URL is required")
+	default:
+		hook := config.Slack
+		hook.Service, hook.Version = appName, appVersion
+		slackHook = &hook
+	}
+
+	// add alert notifiers
+	var alertHook *alerting.Hook
+	if config.Alerting.Enabled() || slackHook != nil {
+		hook, err := config.Alerting.NewHook(appName, appVersion)
+		if err != nil {
+			return nil, fmt.Errorf("cannot configure alerting: %v", err)
+		}
+		alertHook = hook
+		hooks = append(hooks, hook)
+		started = append(started, hook)
+
+		if slackHook != nil {
+			if err := hook.Add("slack", slackHook.Rule(), slackHook); err != nil {
+				return nil, fmt.Errorf("cannot configure slack: %v", err)
+			}
+		}
+	}
+
+	l.SetLevel(logLevel)
+
 	// * set output
 	switch config.Output {
 	case "stderr":
-		logger.SetOutput(os.Stderr)
+		l.SetOutput(os.Stderr)
 	case "stdout":
-		logger.SetOutput(os.Stdout)
+		l.SetOutput(os.Stdout)
 	case "discard":
-		logger.SetOutput(ioutil.Discard)
+		l.SetOutput(ioutil.Discard)
 	default:
 	}
 
+	// set log report caller
+	l.SetReportCaller(config.SetReporterCaller)
+
+	// * add hooks, behind the sampler if enabled
+	if config.state == nil {
+		config.state = &state{}
+	}
+	s := config.state
+
+	// stop watching for the previous initialization and send the queued entries
+	// of the replaced hooks, once the new ones are set
+	var watcher *confighelper.Watcher[Logger]
+	var replaced []closer
+	defer func() {
+		if watcher != nil {
+			watcher.Close()
+		}
+		if err := closeHooks(replaced); err != nil {
+			l.Errorf("cannot close replaced log hooks: %v", err)
+		}
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	levelHooks, replaced := replaceOwnHooks(l.Hooks, hooks)
+	watcher, s.watcher = s.watcher, nil
+	config.Logger = l
+	s.logger, s.sampler = l, nil
+	s.slackHook, s.alertHook, s.forwardHook, s.otlpHook = slackHook, alertHook, forwardHook, otlpHook
+	s.appName, s.appVersion = appName, appVersion
+
+	if config.Sampling.Enabled {
+		s.sampler = newSampler(config.Sampling, l, levelHooks)
+		levelHooks = make(logger.LevelHooks)
+		levelHooks.Add(s.sampler)
+	}
+	l.ReplaceHooks(levelHooks)
+
+	// * set log formatter
+	if formatter == nil {
+		formatter = unwrapFormatter(l.Formatter)
+	}
+	s.setFormatter(formatter)
+
+	l.Debugf("log level: %v", config.Level)
+	if slackIgnored {
+		l.Warn("SLACK is ignored, as ALERTING.SLACK is enabled")
+	}
+
+	return l, nil
+}
+
+// replaceOwnHooks returns the hooks of current without the hooks added by a previous
+// initialization, plus hooks, and the replaced hooks to close. Hooks added by others
+// are kept, also if they were behind a sampler, which is closed.
+func replaceOwnHooks(current logger.LevelHooks, hooks []logger.Hook) (logger.LevelHooks, []closer) {
+	replaced := make(logger.LevelHooks)
+	var closers []closer
+	seen := map[logger.Hook]bool{}
+	keep := func(level logger.Level, h logger.Hook) {
+		if !isOwnHook(h) {
+			replaced[level] = append(replaced[level], h)
+			return
+		}
+		if c, ok := h.(closer); ok && !seen[h] {
+			seen[h] = true
+			closers = append(closers, c)
+		}
+	}
+
+	for level, hs := range current {
+		for _, h := range hs {
+			if s, ok := h.(*sampler); ok {
+				s.close()
+				s.mu.Lock()
+				for _, h := range s.hooks[level] {
+					keep(level, h)
+				}
+				s.mu.Unlock()
+				continue
+			}
+			keep(level, h)
+		}
+	}
+
+	for _, h := range hooks {
+		replaced.Add(h)
+	}
+	return replaced, closers
+}
+
+// closer is a hook sending queued entries on Close.
+type closer interface {
+	Close(ctx context.Context) error
+}
+
+// hookCloseTimeout limits sending the queued entries of hooks closed by initialize.
+const hookCloseTimeout = 5 * time.Second
+
+// closeHooks closes hooks within hookCloseTimeout, returning the first error.
+func closeHooks(hooks []closer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookCloseTimeout)
+	defer cancel()
+
+	var err error
+	for _, h := range hooks {
+		if herr := h.Close(ctx); herr != nil && err == nil {
+			err = herr
+		}
+	}
+	return err
+}
+
+// isOwnHook reports whether h is a hook added by initialize.
+func isOwnHook(h logger.Hook) bool {
+	switch h.(type) {
+	case *alerting.Hook, *forward.Hook, *otlplog.Hook, *sampler:
+		return true
+	}
+	return false
+}
+
+// formatter creates the log formatter set in the config.
+// It returns nil for unknown formatters.
+func (config *Logger) formatter(appName string, appVersion string) (logger.Formatter, error) {
+
 	// * parse field map
 	fieldMap := logger.FieldMap{}
 	if config.FieldMap != "" {
-		err = json.Unmarshal([]byte(config.FieldMap), &fieldMap)
+		err := json.Unmarshal([]byte(config.FieldMap), &fieldMap)
 		if err != nil {
 			return nil, fmt.Errorf("cannot parse logFieldMap %v, error: %v", config.FieldMap, err)
 		}
 	}
 
-	// * set log formatter
 	switch config.Formatter {
 	case "text":
-		logger.SetFormatter(&logger.TextFormatter{FieldMap: fieldMap, DisableColors: false, ForceColors: true})
+		return &logger.TextFormatter{FieldMap: fieldMap, DisableColors: false, ForceColors: true}, nil
 	case "json":
-		logger.SetFormatter(&logger.JSONFormatter{FieldMap: fieldMap, PrettyPrint: config.PrettyPrint})
+		return &logger.JSONFormatter{FieldMap: fieldMap, PrettyPrint: config.PrettyPrint}, nil
 	case "sd":
-		logger.SetFormatter(stackdriver.NewFormatter(
+		options := []stackdriver.Option{
 			stackdriver.WithService(appName),
 			stackdriver.WithVersion(appVersion),
-		))
-		logger.Debug("set stackdriver log formatter")
-	}
-
-	// set log report caller
-	logger.SetReportCaller(config.SetReporterCaller)
-
-	// log external
-	if config.External {
-		hook, err := logrus_fluent.NewWithConfig(logrus_fluent.Config{
-			Host: config.FluentdHost,
-			Port: config.FluentdPort,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("cannot configure new logrus webhook to FluentD: %v", err)
+			stackdriver.WithLabelPrefix(config.SDLabelPrefix),
 		}
-		logger.AddHook(hook)
-	}
-
-	//  add slack
-	if config.Slack.Username == "" {
-		config.Slack.Username = appName
+		if config.SDProjectID != "" {
+			options = append(options, stackdriver.WithProjectID(config.SDProjectID))
+		}
+		return stackdriver.NewFormatter(options...), nil
+	case "otlp":
+		return otlplog.NewFormatter(appName, appVersion, config.OTLP.ResourceAttributes), nil
 	}
-	logger.AddHook(&config.Slack)
-
-	logger.Debugf("log level: %v", config.Level)
-
-	return logger.StandardLogger(), nil
+	return nil, nil
 }
@@ -0,0 +1,461 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onmi-bv/commons/internal/alerting"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/protobuf/proto"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// countHooks returns the number of hooks fired for level.
+func countHooks(l *logger.Logger, level logger.Level) int {
+	return len(l.Hooks[level])
+}
+
+func TestInitializeDedicated(t *testing.T) {
+	std := logger.StandardLogger()
+	stdLevel, stdHooks := std.GetLevel(), countHooks(std, logger.ErrorLevel)
+
+	debug := NewLogger()
+	debug.Level = "debug"
+	debug.Output = "discard"
+	a, err := debug.Initialize(context.Background(), "a", "1.0.0")
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	warn := NewLogger()
+	warn.Level = "warn"
+	warn.Output = "discard"
+	b, err := warn.Initialize(context.Background(), "b", "1.0.0")
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	if a == b || a == std || b == std {
+		t.Fatal("Initialize() does not create a dedicated logger")
+	}
+	if a.GetLevel() != logger.DebugLevel || b.GetLevel() != logger.WarnLevel {
+		t.Errorf("levels = %v, %v, want debug, warning", a.GetLevel(), b.GetLevel())
+	}
+	if std.GetLevel() != stdLevel || countHooks(std, logger.ErrorLevel) != stdHooks {
+		t.Error("Initialize() changed the standard logger")
+	}
+}
+
+func TestInitializeStandardIdempotent(t *testing.T) {
+	std := logger.StandardLogger()
+	defer std.ReplaceHooks(std.ReplaceHooks(make(logger.LevelHooks)))
+	defer setBase(logger.NewEntry(std))
+
+	other := &otherHook{}
+	std.AddHook(other)
+
+	for i := 0; i < 3; i++ {
+		c := NewLogger()
+		c.Output = "discard"
+		c.Slack.HookURL = "http://127.0.0.1"
+		if _, err := c.InitializeStandard(context.Background(), "app", "1.0.0"); err != nil {
+			t.Fatalf("InitializeStandard() error = %v", err)
+		}
+	}
+
+	// the alerting hook sending to slack and the hook added by others
+	var own, others int
+	for _, h := range std.Hooks[logger.PanicLevel] {
+		if isOwnHook(h) {
+			own++
+		} else if h == other {
+			others++
+		}
+	}
+	if own != 1 || others != 1 {
+		t.Errorf("standard logger has %d own and %d other hooks, want 1 and 1", own, others)
+	}
+}
+
+type otherHook struct{}
+
+func (h *otherHook) Levels() []logger.Level {
+	return logger.AllLevels
+}
+
+func (h *otherHook) Fire(*logger.Entry) error {
+	return nil
+}
+
+func TestInitializeStandardClosesReplaced(t *testing.T) {
+	std := logger.StandardLogger()
+	defer std.ReplaceHooks(std.ReplaceHooks(make(logger.LevelHooks)))
+	defer setBase(logger.NewEntry(std))
+
+	alerts := make(chan alerting.Alert, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a alerting.Alert
+		json.NewDecoder(r.Body).Decode(&a)
+		alerts <- a
+	}))
+	defer s.Close()
+
+	c := NewLogger()
+	c.Output = "discard"
+	c.Alerting.Webhook.Enabled = true
+	c.Alerting.Webhook.URL = s.URL
+	l, err := c.InitializeStandard(context.Background(), "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("InitializeStandard() error = %v", err)
+	}
+	l.Error("queued")
+
+	c = NewLogger()
+	c.Output = "discard"
+	if _, err := c.InitializeStandard(context.Background(), "app", "1.0.0"); err != nil {
+		t.Fatalf("InitializeStandard() error = %v", err)
+	}
+
+	// the replaced hook sent its queued alert before InitializeStandard returned
+	select {
+	case <-alerts:
+	default:
+		t.Error("no alert sent by the replaced hook")
+	}
+}
+
+func TestInitializeAlerting(t *testing.T) {
+	alerts := make(chan alerting.Alert, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a alerting.Alert
+		json.NewDecoder(r.Body).Decode(&a)
+		alerts <- a
+	}))
+	defer s.Close()
+
+	c := NewLogger()
+	c.Output = "discard"
+	c.Alerting.Webhook.Enabled = true
+	c.Alerting.Webhook.URL = s.URL
+	l, err := c.Initialize(context.Background(), "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	l.Warn("not alerted")
+	l.WithField("user", "bob").Error("cannot read")
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	select {
+	case a := <-alerts:
+		if a.Title != "[app] error: cannot read" || a.Fields["user"] != "bob" {
+			t.Errorf("alert = %+v, want the error entry", a)
+		}
+	default:
+		t.Fatal("no alert sent")
+	}
+	if len(alerts) != 0 {
+		t.Error("warning was alerted below the error level")
+	}
+}
+
+func TestInitializeSlack(t *testing.T) {
+	var legacy, alerts int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/alerts" {
+			atomic.AddInt32(&alerts, 1)
+		} else {
+			atomic.AddInt32(&legacy, 1)
+		}
+	}))
+	defer s.Close()
+
+	c := NewLogger()
+	c.Output = "discard"
+	c.Slack.Disabled = false
+	if _, err := c.Initialize(context.Background(), "app", "1.0.0"); err == nil {
+		t.Fatal("Initialize() error = nil, want the required slack hook url")
+	}
+
+	// queued slack messages are sent on Close
+	c.Slack.HookURL = s.URL
+	c.Slack.Asynchronous = true
+	c.Slack.BatchWindow = time.Hour
+	l, err := c.Initialize(context.Background(), "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	l.Error("queued")
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if n := atomic.LoadInt32(&legacy); n != 1 {
+		t.Fatalf("sent %d slack messages, want 1", n)
+	}
+
+	// ALERTING.SLACK replaces SLACK, so entries are sent once
+	c.Alerting.Slack.Enabled = true
+	c.Alerting.Slack.HookURL = s.URL + "/alerts"
+	c.Alerting.Slack.Level = "error"
+	l, err = c.Initialize(context.Background(), "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	l.Error("alerted")
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if n, m := atomic.LoadInt32(&legacy), atomic.LoadInt32(&alerts); n != 1 || m != 1 {
+		t.Errorf("sent %d slack messages and %d alerts, want only the alert", n-1, m)
+	}
+}
+
+func TestInitializeExternalUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	c := NewLogger()
+	c.Output = "discard"
+	c.External = true
+	c.FluentdPort = port
+	c.FluentdFallback = "discard"
+	l, err := c.Initialize(context.Background(), "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("Initialize() error = %v, want buffering while fluentd is down", err)
+	}
+	defer c.Close(context.Background())
+
+	l.Info("buffered")
+	if m, ok := c.ForwardMetrics(); !ok || m.Buffered != 1 {
+		t.Errorf("ForwardMetrics() = %+v, %v, want 1 buffered", m, ok)
+	}
+}
+
+func TestInitializeErrorClosesHooks(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	c := NewLogger()
+	c.Output = "discard"
+	c.External = true
+	c.FluentdPort = 1
+	c.Alerting.Webhook.Enabled = true
+	c.Alerting.Webhook.Title = "{{"
+	if _, err := c.Initialize(context.Background(), "app", "1.0.0"); err == nil {
+		t.Fatal("Initialize() error = nil, want the invalid alert title")
+	}
+
+	// the worker of the fluentd hook stops when the hook is closed
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines after a failed Initialize, want %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInitializeExternalOTLP(t *testing.T) {
+	requests := make(chan *collogspb.ExportLogsServiceRequest, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := &collogspb.ExportLogsServiceRequest{}
+		proto.Unmarshal(body, req)
+		requests <- req
+	}))
+	defer s.Close()
+
+	c := NewLogger()
+	c.Output = "discard"
+	c.External = true
+	c.ExternalOutput = "otlp"
+	c.OTLP.Protocol = "http"
+	c.OTLP.Endpoint = s.URL
+	l, err := c.Initialize(context.Background(), "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+
+	l.Info("exported")
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	req := <-requests
+	rl := req.ResourceLogs[0]
+	if name := rl.Resource.Attributes[0].Value.GetStringValue(); name != "app" {
+		t.Errorf("service name = %q, want app", name)
+	}
+	if body := rl.ScopeLogs[0].LogRecords[0].Body.GetStringValue(); body != "exported" {
+		t.Errorf("body = %q, want exported", body)
+	}
+}
+
+func TestInitWatch(t *testing.T) {
+	var sent int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&sent, 1)
+	}))
+	defer s.Close()
+
+	path := filepath.Join(t.TempDir(), "log.yaml")
+	write := func(level string, formatter string, slackDisabled bool) {
+		config := fmt.Sprintf("OUTPUT: discard\nLEVEL: %s\nFORMATTER: %s\nSLACK:\n  HOOK_URL: %s\n  DISABLED: %v\n", level, formatter, s.URL, slackDisabled)
+		if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("info", "text", true)
+
+	l, err := Init(context.Background(), Configuration{AppName: "app", Version: "1.0.0", Path: path, Prefix: "watchlog", Watch: true})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer l.Close(context.Background())
+
+	l.Error("not sent")
+	if atomic.LoadInt32(&sent) != 0 {
+		t.Fatal("slack message sent while disabled")
+	}
+
+	write("debug", "json", false)
+	deadline := time.Now().Add(5 * time.Second)
+	for l.GetLevel() != logger.DebugLevel {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for the level change")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the change is applied at once, under the lock of the state
+	l.state.mu.Lock()
+	formatter := l.Logger.Formatter
+	l.state.mu.Unlock()
+
+	if _, ok := unwrapFormatter(formatter).(*logger.JSONFormatter); !ok {
+		t.Errorf("formatter = %T, want the json formatter", formatter)
+	}
+	l.Error("sent")
+	for atomic.LoadInt32(&sent) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("sent %d slack messages, want 1 once enabled", atomic.LoadInt32(&sent))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInitWatchClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.yaml")
+	write := func(level string) {
+		if err := os.WriteFile(path, []byte("OUTPUT: discard\nLEVEL: "+level+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("info")
+
+	l, err := Init(context.Background(), Configuration{AppName: "app", Path: path, Prefix: "watchclose", Watch: true})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if l.state.watcher != nil {
+		t.Fatal("Close() kept the watcher")
+	}
+
+	write("debug")
+	time.Sleep(200 * time.Millisecond)
+	if l.GetLevel() != logger.InfoLevel {
+		t.Errorf("level = %v after Close, want info", l.GetLevel())
+	}
+}
+
+func TestInitializeClosesWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.yaml")
+	if err := os.WriteFile(path, []byte("OUTPUT: discard\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Init(context.Background(), Configuration{AppName: "app", Path: path, Prefix: "watchreinit", Watch: true})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer l.Close(context.Background())
+
+	if _, err := l.Initialize(context.Background(), "app", "1.0.0"); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if l.state.watcher != nil {
+		t.Fatal("Initialize() kept the watcher of the previous initialization")
+	}
+
+	if err := os.WriteFile(path, []byte("OUTPUT: discard\nLEVEL: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if l.GetLevel() != logger.InfoLevel {
+		t.Errorf("level = %v, want info once the watcher is closed", l.GetLevel())
+	}
+}
+
+func TestInitExternal(t *testing.T) {
+	tests := []struct {
+		external string
+		output   string
+		fluentd  bool
+		otlp     bool
+	}{
+		{external: "false"},
+		{external: "FALSE", output: "otlp"},
+		{external: "true", fluentd: true},
+		{external: "TRUE", output: "Fluentd", fluentd: true},
+		{external: "1", output: "OTLP", otlp: true},
+		{external: "True", output: "otlp", otlp: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.external+" "+tt.output, func(t *testing.T) {
+			t.Setenv("EXT_OUTPUT", "discard")
+			t.Setenv("EXT_FLUENTD_PORT", "1")
+			t.Setenv("EXT_FLUENTD_FALLBACK", "discard")
+			t.Setenv("EXT_OTLP_INSECURE", "true")
+			t.Setenv("EXT_EXTERNAL", tt.external)
+			if tt.output != "" {
+				t.Setenv("EXT_EXTERNAL_OUTPUT", tt.output)
+			}
+
+			l, err := Init(context.Background(), Configuration{AppName: "app", Prefix: "ext"})
+			if err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				defer cancel()
+				l.Close(ctx)
+			}()
+
+			if fluentd := l.state.forwardHook != nil; fluentd != tt.fluentd {
+				t.Errorf("fluentd output = %v, want %v", fluentd, tt.fluentd)
+			}
+			if otlp := l.state.otlpHook != nil; otlp != tt.otlp {
+				t.Errorf("otlp output = %v, want %v", otlp, tt.otlp)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"time"
+
+	"github.com/onmi-bv/commons/logger/internals/otlplog"
+)
+
+// OTLP configures the OpenTelemetry log records of FORMATTER=otlp and their export with EXTERNAL_OUTPUT=otlp.
+type OTLP struct {
+	// Endpoint sets the collector address: host:port for grpc, a URL or host:port for http.
+	Endpoint string `mapstructure:"ENDPOINT" default:"localhost:4317"`
+
+	// Protocol sets the export protocol. I.e., grpc, http (protobuf)
+	Protocol string `mapstructure:"PROTOCOL" default:"grpc" validate:"oneof=grpc http"`
+
+	// Insecure disables TLS.
+	Insecure bool `mapstructure:"INSECURE" default:"false"`
+
+	// Headers are sent with the exports, i.e. authorization=Bearer token.
+	Headers map[string]string `mapstructure:"HEADERS" secret:"true"`
+
+	// ResourceAttributes are added to the service name and version of the resource, i.e. deployment.environment=prod.
+	ResourceAttributes map[string]string `mapstructure:"RESOURCE_ATTRIBUTES"`
+
+	// QueueSize limits the records queued for export; more are dropped.
+	QueueSize int `mapstructure:"QUEUE_SIZE" default:"2048" validate:"min=1"`
+
+	// BatchSize sets the records per export.
+	BatchSize int `mapstructure:"BATCH_SIZE" default:"512" validate:"min=1"`
+
+	// BatchInterval sets the delay of exporting a partial batch.
+	BatchInterval time.Duration `mapstructure:"BATCH_INTERVAL" default:"1s" validate:"min=1ms"`
+
+	// Timeout limits an export.
+	Timeout time.Duration `mapstructure:"TIMEOUT" default:"10s" validate:"min=1ms"`
+}
+
+// newHook creates the hook exporting the entries of the app as log records.
+func (c OTLP) newHook(appName string, appVersion string) (*otlplog.Hook, error) {
+	exporter, err := otlplog.NewExporter(c.Protocol, c.Endpoint, c.Insecure, c.Headers)
+	if err != nil {
+		return nil, err
+	}
+	return otlplog.NewHook(otlplog.NewResource(appName, appVersion, c.ResourceAttributes), exporter, otlplog.HookConfig{
+		QueueSize:     c.QueueSize,
+		BatchSize:     c.BatchSize,
+		BatchInterval: c.BatchInterval,
+		Timeout:       c.Timeout,
+	}), nil
+}
@@ -0,0 +1,226 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Sampling limits repeated log entries. Per interval, the first Initial entries with the
+// same message and level are logged, then every Thereafter-th. Fatal and panic entries
+// are always logged. Dropped entries reach neither the output nor the hooks.
+type Sampling struct {
+	// Enabled enables sampling.
+	Enabled bool `mapstructure:"ENABLED" default:"false"`
+
+	// Initial sets the number of entries logged per message and level per interval.
+	Initial int `mapstructure:"INITIAL" default:"100" validate:"min=0"`
+
+	// Thereafter sets the rate of the entries logged after Initial, i.e. 100 logs 1 in 100.
+	// Zero drops all entries after Initial.
+	Thereafter int `mapstructure:"THEREAFTER" default:"100" validate:"min=0"`
+
+	// Interval sets the period after which the counts are reset.
+	Interval time.Duration `mapstructure:"INTERVAL" default:"1s" validate:"min=1ms"`
+
+	// SummaryInterval sets the period of the "suppressed X messages" summary. Zero disables it.
+	SummaryInterval time.Duration `mapstructure:"SUMMARY_INTERVAL" default:"1m" validate:"min=0s"`
+}
+
+type sampleKey struct {
+	level   logger.Level
+	message string
+}
+
+// sampler is the only hook of a sampled logger. It fires the hooks of the
+// logger for sampled entries and marks the others to be dropped by the formatter.
+type sampler struct {
+	config Sampling
+	logger *logger.Logger
+
+	mu         sync.Mutex
+	hooks      logger.LevelHooks
+	counts     map[sampleKey]int
+	windowEnd  time.Time
+	sampled    map[logger.Level]uint64
+	suppressed map[logger.Level]uint64
+	reported   map[logger.Level]uint64 // suppressed count at the last summary
+
+	dropped sync.Map // *logger.Entry
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func newSampler(config Sampling, l *logger.Logger, hooks logger.LevelHooks) *sampler {
+	s := &sampler{
+		config:     config,
+		logger:     l,
+		hooks:      hooks,
+		counts:     map[sampleKey]int{},
+		sampled:    map[logger.Level]uint64{},
+		suppressed: map[logger.Level]uint64{},
+		reported:   map[logger.Level]uint64{},
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	if config.SummaryInterval > 0 {
+		go s.summarize()
+	} else {
+		close(s.done)
+	}
+	return s
+}
+
+// Levels implements logger.Hook.
+func (s *sampler) Levels() []logger.Level {
+	return logger.AllLevels
+}
+
+// Fire implements logger.Hook, firing the hooks of the logger if e is sampled.
+func (s *sampler) Fire(e *logger.Entry) error {
+	if !s.sample(e.Level, e.Message, time.Now()) {
+		s.dropped.Store(e, struct{}{})
+		return nil
+	}
+
+	s.mu.Lock()
+	hooks := make([]logger.Hook, len(s.hooks[e.Level]))
+	copy(hooks, s.hooks[e.Level])
+	s.mu.Unlock()
+
+	var err error
+	for _, h := range hooks {
+		if herr := h.Fire(e); herr != nil && err == nil {
+			err = herr
+		}
+	}
+	return err
+}
+
+// sample reports whether an entry is logged, counting it per level.
+func (s *sampler) sample(level logger.Level, message string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if level <= logger.FatalLevel {
+		s.sampled[level]++
+		return true
+	}
+
+	if now.After(s.windowEnd) {
+		s.counts = map[sampleKey]int{}
+		s.windowEnd = now.Add(s.config.Interval)
+	}
+
+	k := sampleKey{level, message}
+	s.counts[k]++
+	n := s.counts[k]
+
+	if n <= s.config.Initial || (s.config.Thereafter > 0 && (n-s.config.Initial)%s.config.Thereafter == 0) {
+		s.sampled[level]++
+		return true
+	}
+	s.suppressed[level]++
+	return false
+}
+
+// isDropped reports whether e was dropped, forgetting it.
+func (s *sampler) isDropped(e *logger.Entry) bool {
+	_, ok := s.dropped.LoadAndDelete(e)
+	return ok
+}
+
+// replaceHook replaces the old hook by new.
+func (s *sampler) replaceHook(old logger.Hook, new logger.Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks = replaceHook(s.hooks, old, new)
+}
+
+// Counts returns the number of sampled and suppressed entries per level.
+func (s *sampler) Counts() (sampled map[logger.Level]uint64, suppressed map[logger.Level]uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sampled = make(map[logger.Level]uint64, len(s.sampled))
+	for l, n := range s.sampled {
+		sampled[l] = n
+	}
+	suppressed = make(map[logger.Level]uint64, len(s.suppressed))
+	for l, n := range s.suppressed {
+		suppressed[l] = n
+	}
+	return sampled, suppressed
+}
+
+// summarize logs the suppressed entries every summary interval until closed.
+func (s *sampler) summarize() {
+	defer close(s.done)
+
+	t := time.NewTicker(s.config.SummaryInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			s.summary()
+		case <-s.stop:
+			s.summary()
+			return
+		}
+	}
+}
+
+// summary logs the entries suppressed since the last summary, if any.
+func (s *sampler) summary() {
+	s.mu.Lock()
+	fields := logger.Fields{}
+	var total uint64
+	for level, n := range s.suppressed {
+		if d := n - s.reported[level]; d > 0 {
+			fields["suppressed_"+level.String()] = d
+			total += d
+		}
+		s.reported[level] = n
+	}
+	s.mu.Unlock()
+
+	if total > 0 {
+		s.logger.WithFields(fields).Warnf("suppressed %d messages", total)
+	}
+}
+
+// close stops the summary, logging the last one.
+func (s *sampler) close() {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	<-s.done
+}
+
+// samplingFormatter formats the entries not dropped by the sampler.
+type samplingFormatter struct {
+	logger.Formatter
+	sampler *sampler
+}
+
+// Format implements logger.Formatter. Dropped entries are formatted as nothing.
+func (f *samplingFormatter) Format(e *logger.Entry) ([]byte, error) {
+	if f.sampler.isDropped(e) {
+		return nil, nil
+	}
+	return f.Formatter.Format(e)
+}
+
+// unwrapFormatter returns the formatter wrapped by a sampling formatter, or f.
+func unwrapFormatter(f logger.Formatter) logger.Formatter {
+	if sf, ok := f.(*samplingFormatter); ok {
+		return sf.Formatter
+	}
+	return f
+}
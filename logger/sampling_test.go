@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Sampling
+		level      logger.Level
+		n          int
+		wantLogged int
+	}{
+		{"initial only", Sampling{Initial: 3, Interval: time.Hour}, logger.InfoLevel, 10, 3},
+		{"thereafter", Sampling{Initial: 2, Thereafter: 4, Interval: time.Hour}, logger.InfoLevel, 10, 4}, // 1, 2, 6, 10
+		{"fatal is not sampled", Sampling{Initial: 1, Interval: time.Hour}, logger.FatalLevel, 5, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSampler(tt.config, logger.New(), nil)
+			defer s.close()
+
+			logged := 0
+			now := time.Now()
+			for i := 0; i < tt.n; i++ {
+				if s.sample(tt.level, "message", now) {
+					logged++
+				}
+			}
+			if logged != tt.wantLogged {
+				t.Errorf("logged %d of %d entries, want %d", logged, tt.n, tt.wantLogged)
+			}
+		})
+	}
+}
+
+func TestSampleInterval(t *testing.T) {
+	s := newSampler(Sampling{Initial: 1, Interval: time.Second}, logger.New(), nil)
+	defer s.close()
+
+	now := time.Now()
+	if !s.sample(logger.InfoLevel, "a", now) || s.sample(logger.InfoLevel, "a", now) {
+		t.Fatal("want only the first entry in the interval")
+	}
+	if !s.sample(logger.InfoLevel, "b", now) || !s.sample(logger.WarnLevel, "a", now) {
+		t.Error("want entries with other messages or levels")
+	}
+	if !s.sample(logger.InfoLevel, "a", now.Add(2*time.Second)) {
+		t.Error("want the first entry of the next interval")
+	}
+}
+
+func TestSamplingLogger(t *testing.T) {
+	c := NewLogger()
+	c.Sampling = Sampling{Enabled: true, Initial: 2, Interval: time.Hour, SummaryInterval: time.Hour}
+
+	var buf bytes.Buffer
+	l, err := c.Initialize(context.Background(), "app", "1.0.0")
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	l.SetOutput(&buf)
+	l.SetFormatter(&samplingFormatter{Formatter: &logger.TextFormatter{DisableTimestamp: true}, sampler: c.state.sampler})
+
+	hook := &firedHook{}
+	c.state.replaceHook(c.state.slackHook, hook)
+
+	for i := 0; i < 5; i++ {
+		l.Error("cannot convert request")
+	}
+
+	if n := strings.Count(buf.String(), "cannot convert request"); n != 2 {
+		t.Errorf("output has %d entries, want 2:\n%s", n, buf.String())
+	}
+	if hook.fired != 2 {
+		t.Errorf("hook fired %d times, want 2", hook.fired)
+	}
+	if _, suppressed := c.SampleCounts(); suppressed[logger.ErrorLevel] != 3 {
+		t.Errorf("suppressed %d error entries, want 3", suppressed[logger.ErrorLevel])
+	}
+
+	c.Close(context.Background())
+	if !strings.Contains(buf.String(), "suppressed 3 messages") {
+		t.Errorf("output has no summary:\n%s", buf.String())
+	}
+}
+
+type firedHook struct {
+	fired int
+}
+
+func (h *firedHook) Levels() []logger.Level {
+	return logger.AllLevels
+}
+
+func (h *firedHook) Fire(*logger.Entry) error {
+	h.fired++
+	return nil
+}
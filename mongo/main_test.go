@@ -6,6 +6,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/onmi-bv/commons/confighelper"
 	"github.com/onmi-bv/commons/testutils"
 	"github.com/stretchr/testify/assert"
 )
@@ -31,7 +32,11 @@ func TestMain(m *testing.M) {
 	os.Setenv("MONGO_PASSWORD", "secret")
 
 	// * setup mongo using docker
-	_, mongoID, _ := testutils.CreateNewContainer("mongo", "27000", "27017", []string{"MONGO_INITDB_ROOT_USERNAME=root", "MONGO_INITDB_ROOT_PASSWORD=secret"})
+	_, mongoID, _ := testutils.CreateNewContainer(context.Background(), testutils.ContainerConfig{
+		Image:   "mongo",
+		PortMap: []testutils.PortMap{{Host: "27000", Container: "27017"}},
+		Env:     []string{"MONGO_INITDB_ROOT_USERNAME=root", "MONGO_INITDB_ROOT_PASSWORD=secret"},
+	})
 	defer testutils.RemoveContainer(mongoID) // make sure to stop container in case of fatal error
 
 	//* run test
@@ -49,6 +54,15 @@ func TestLoadAndInit(t *testing.T) {
 	assert.NotEmpty(t, m, "Expected a mongo client.")
 }
 
+func TestHealthcheck(t *testing.T) {
+	ctx := context.Background()
+	c, err := Init(ctx, Configuration{AppName: "testmongo", Prefix: "mongo"})
+	assert.NoErrorf(t, err, "cannot init mongo client: %v", err)
+	defer c.Disconnect(ctx)
+
+	assert.NoError(t, c.Healthcheck(ctx))
+}
+
 func TestInit(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -132,3 +146,18 @@ func TestInit(t *testing.T) {
 	// cleanup
 	// service.mongo.Database("test").Collection(service.mongoConfig.Collection).Drop(service.ctx)
 }
+
+func TestLoadSecrets(t *testing.T) {
+	t.Setenv("MONGOSECRET_URI", "mongodb://localhost:27000")
+	t.Setenv("MONGOSECRET_PASSWORD", "secret://mongo-password")
+
+	secrets := confighelper.ResolverFunc(func(ctx context.Context, name string) (string, error) {
+		return "resolved " + name, nil
+	})
+	c, err := load(context.Background(), confighelper.Options("", "mongosecret", secrets)...)
+	assert.NoError(t, err)
+	assert.Equal(t, "resolved mongo-password", c.Password)
+
+	_, err = load(context.Background(), confighelper.Options("", "mongosecret", nil)...)
+	assert.Error(t, err, "want an error for a secret without resolver")
+}
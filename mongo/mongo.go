@@ -8,17 +8,18 @@ import (
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // Config defines connection configurations
 type Config struct {
-	URI         string `mapstructure:"URI"`
+	URI         string `mapstructure:"URI" required:"true" validate:"url"`
 	Database    string `mapstructure:"DATABASE"`
 	Collection  string `mapstructure:"COLLECTION"`
 	AuthEnabled bool   `mapstructure:"AUTH_ENABLED"`
 	Username    string `mapstructure:"USERNAME"`
 	Source      string `mapstructure:"SOURCE"`
-	Password    string `mapstructure:"PASSWORD"`
+	Password    string `mapstructure:"PASSWORD" secret:"true"`
 }
 
 // NewConfig creates a config struct with the connection default values
@@ -49,6 +50,7 @@ func (c *Config) Initialize(ctx context.Context, appName string) (*mongo.Client,
 
 	err = m.Ping(ctx, nil)
 	if err != nil {
+		m.Disconnect(ctx)
 		return nil, fmt.Errorf("cannot ping Mongo server: %v", err.Error())
 	}
 	return m, nil
@@ -65,28 +67,51 @@ func LoadAndInitialize(ctx context.Context, cFile string, prefix string, appName
 	return
 }
 
-// Load loads redis configuration from file and environment.
-func Load(ctx context.Context, cFile string, prefix string) (c Config, err error) {
+// Client holds a connected mongo client with its configuration.
+type Client struct {
+	*mongo.Client
+	Config Config
+}
+
+// Configuration used for initialization
+type Configuration struct {
+	AppName string
+	Path    string                // Path to config file.
+	Prefix  string                // Prefix to environment variables.
+	Secrets confighelper.Resolver // Resolves secret:// references, i.e. for the password.
+	Sources []confighelper.Option // Layered sources, i.e. confighelper.WithDotEnv, applied after Path and Prefix.
+}
+
+// Init loads configuration from file or environment and connects.
+func Init(ctx context.Context, conf Configuration) (Client, error) {
+	c, err := load(ctx, confighelper.Options(conf.Path, conf.Prefix, conf.Secrets, conf.Sources...)...)
+	if err != nil {
+		return Client{Config: c}, err
+	}
+	m, err := c.Initialize(ctx, conf.AppName)
+	return Client{Client: m, Config: c}, err
+}
+
+// Healthcheck checks if the mongo server is online using ping.
+func (c *Client) Healthcheck(ctx context.Context) error {
+	return c.Ping(ctx, readpref.Primary())
+}
+
+// Load loads mongo configuration from file and environment.
+func Load(ctx context.Context, cFile string, prefix string) (Config, error) {
+	return load(ctx, confighelper.WithFile(cFile), confighelper.WithPrefix(prefix))
+}
+
+func load(ctx context.Context, opts ...confighelper.Option) (c Config, err error) {
 	c = NewConfig()
 
-	err = confighelper.ReadConfig(cFile, prefix, &c)
+	l := confighelper.New(opts...)
+	err = l.LoadContext(ctx, &c)
 	if err != nil {
 		return
 	}
 
-	log.Debugf("# Mongo config... ")
-	log.Debugf("Mongo URI: %v", c.URI)
-	log.Debugf("Mongo database: %v", c.Database)
-	log.Debugf("Mongo collection: %v", c.Collection)
-	log.Debugf("Mongo auth enabled: %v", c.AuthEnabled)
-	log.Debugf("Mongo source: %v", c.Source)
-	log.Debugf("Mongo username: %v", c.Username)
-	if c.Password != "" {
-		log.Debugf("Mongo password: %v", "***")
-	} else {
-		log.Debugf("Mongo password: %v", "<empty>")
-	}
-	log.Debugln("...")
+	log.WithFields(l.Describe(&c).Fields()).Debug("mongo config")
 
 	return
 }
@@ -8,9 +8,50 @@ import (
 	"time"
 
 	redis "github.com/go-redis/redis/v8"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// instrumentationName is the name of the tracer of the helpers.
+const instrumentationName = "github.com/onmi-bv/commons/redis/helper"
+
+// Span attributes, besides db.system=redis.
+const (
+	namespaceKey     = attribute.Key("db.redis.namespace")
+	lockNamespaceKey = attribute.Key("db.redis.lock_namespace")
+	jobNameKey       = attribute.Key("job.name")
+	lockOutcomeKey   = attribute.Key("lock.outcome")
+)
+
+// Lock outcomes.
+const (
+	lockAcquired    = "acquired"
+	lockNotAcquired = "not_acquired"
+	lockReleased    = "released"
+	lockNotReleased = "not_released"
+	lockNoJob       = "no_job"
+)
+
+// startSpan starts a client span of the global tracer provider, as set by tracing.Init.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append([]attribute.KeyValue{semconv.DBSystemRedis}, attrs...)...),
+	)
+}
+
+// endSpan records err, if any, and ends span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
 // Job defines user updates
 type Job struct {
 	Name string      // name format: tag1=val1;tag2=val2 (i.e., user=test)
@@ -21,8 +62,8 @@ type Job struct {
 // Find a pending task to be processed.
 // Jobs are locked before returning.
 func Find(ctx context.Context, r redis.Cmdable, jobNS string, lockNS string, uuid string, timeout int) (jChan chan Job, err error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "FindJob")
-	defer span.Finish()
+	ctx, span := startSpan(ctx, "FindJob", namespaceKey.String(jobNS), lockNamespaceKey.String(lockNS))
+	defer func() { endSpan(span, err) }()
 
 	jChan = make(chan Job, 1)
 
@@ -54,11 +95,13 @@ func Find(ctx context.Context, r redis.Cmdable, jobNS string, lockNS string, uui
 
 	// error in redis evaluation
 	if err != nil {
-		panic(fmt.Sprintf("cannot get job: '%v'", err))
+		err = fmt.Errorf("cannot get job: '%v'", err)
+		return
 	}
 
 	// no job received
 	if j == "none" {
+		span.SetAttributes(lockOutcomeKey.String(lockNoJob))
 		return
 	}
 
@@ -66,6 +109,7 @@ func Find(ctx context.Context, r redis.Cmdable, jobNS string, lockNS string, uui
 	jmap := j.([]interface{})
 	n := jmap[0].(string)
 	t := jmap[1].(string)
+	span.SetAttributes(jobNameKey.String(n), lockOutcomeKey.String(lockAcquired))
 	tt, err := strconv.ParseInt(t, 0, 64)
 
 	if err != nil {
@@ -80,9 +124,9 @@ func Find(ctx context.Context, r redis.Cmdable, jobNS string, lockNS string, uui
 // Unlock attempts to remove the lock on a key so long as the value matches.
 // If the lock cannot be removed, either because the key has already expired or
 // because the value was incorrect, an error will be returned.
-func Unlock(ctx context.Context, r redis.Cmdable, lockNS string, key string, uuid string) (bool, error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "UnlockJob")
-	defer span.Finish()
+func Unlock(ctx context.Context, r redis.Cmdable, lockNS string, key string, uuid string) (ok bool, err error) {
+	ctx, span := startSpan(ctx, "UnlockJob", lockNamespaceKey.String(lockNS), jobNameKey.String(key))
+	defer func() { endSpan(span, err) }()
 
 	unlock, err := r.Eval(ctx, `
 	redis.replicate_commands()
@@ -97,13 +141,15 @@ func Unlock(ctx context.Context, r redis.Cmdable, lockNS string, key string, uui
 	if err != nil {
 		return false, err
 	}
-	return unlock.(int64) == 1, err
+	ok = unlock.(int64) == 1
+	span.SetAttributes(lockOutcomeKey.String(outcome(ok, lockReleased, lockNotReleased)))
+	return ok, err
 }
 
 // Remove removes job from redis
-func Remove(ctx context.Context, r redis.Cmdable, jobNS string, j Job) (bool, error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "RemoveJob")
-	defer span.Finish()
+func Remove(ctx context.Context, r redis.Cmdable, jobNS string, j Job) (ok bool, err error) {
+	ctx, span := startSpan(ctx, "RemoveJob", namespaceKey.String(jobNS), jobNameKey.String(j.Name))
+	defer func() { endSpan(span, err) }()
 
 	res, err := r.Eval(ctx, `
 	redis.replicate_commands()
@@ -126,9 +172,9 @@ func Remove(ctx context.Context, r redis.Cmdable, jobNS string, j Job) (bool, er
 
 // ExLock sets the expiry of already owned lock
 // TODO: test
-func ExLock(ctx context.Context, r redis.Cmdable, key string, uuid string, timeout int) (bool, error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ExLock")
-	defer span.Finish()
+func ExLock(ctx context.Context, r redis.Cmdable, key string, uuid string, timeout int) (ok bool, err error) {
+	ctx, span := startSpan(ctx, "ExLock", jobNameKey.String(key))
+	defer func() { endSpan(span, err) }()
 
 	lock, err := r.Eval(ctx, `
 	redis.replicate_commands()
@@ -143,19 +189,21 @@ func ExLock(ctx context.Context, r redis.Cmdable, key string, uuid string, timeo
 	if err != nil {
 		return false, err
 	}
-	return lock.(int64) == 1, err
+	ok = lock.(int64) == 1
+	span.SetAttributes(lockOutcomeKey.String(outcome(ok, lockAcquired, lockNotAcquired)))
+	return ok, err
 }
 
 // Add adds the update to redis
-func Add(ctx context.Context, r redis.Cmdable, jobNS string, j Job) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "AddJob")
-	defer span.Finish()
+func Add(ctx context.Context, r redis.Cmdable, jobNS string, j Job) (err error) {
+	ctx, span := startSpan(ctx, "AddJob", namespaceKey.String(jobNS), jobNameKey.String(j.Name))
+	defer func() { endSpan(span, err) }()
 
 	if j == (Job{}) {
 		return fmt.Errorf("cannot add empty job")
 	}
 	z := redis.Z{Member: j.Name, Score: float64(j.Time)}
-	_, err := r.ZAdd(ctx, jobNS, &z).Result()
+	_, err = r.ZAdd(ctx, jobNS, &z).Result()
 	if err != nil {
 		return err
 	}
@@ -163,9 +211,9 @@ func Add(ctx context.Context, r redis.Cmdable, jobNS string, j Job) error {
 }
 
 // Set sets a state which expires. It uses Redis Set command.
-func Set(ctx context.Context, r redis.Cmdable, ns string, name string, value interface{}, ttl time.Duration) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "Set")
-	defer span.Finish()
+func Set(ctx context.Context, r redis.Cmdable, ns string, name string, value interface{}, ttl time.Duration) (err error) {
+	ctx, span := startSpan(ctx, "Set", namespaceKey.String(ns))
+	defer func() { endSpan(span, err) }()
 
 	// persist fake state
 	b, err := json.Marshal(value)
@@ -181,9 +229,9 @@ func Set(ctx context.Context, r redis.Cmdable, ns string, name string, value int
 }
 
 // Get gets user state from redis using the Get command.
-func Get(ctx context.Context, r redis.Cmdable, ns string, name string, value interface{}) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "Get")
-	defer span.Finish()
+func Get(ctx context.Context, r redis.Cmdable, ns string, name string, value interface{}) (err error) {
+	ctx, span := startSpan(ctx, "Get", namespaceKey.String(ns))
+	defer func() { endSpan(span, err) }()
 
 	key := ns + ":" + name
 
@@ -207,9 +255,9 @@ func Get(ctx context.Context, r redis.Cmdable, ns string, name string, value int
 }
 
 // HSet sets a state using the HSet command.
-func HSet(ctx context.Context, r redis.Cmdable, ns string, name string, value interface{}) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "HSet")
-	defer span.Finish()
+func HSet(ctx context.Context, r redis.Cmdable, ns string, name string, value interface{}) (err error) {
+	ctx, span := startSpan(ctx, "HSet", namespaceKey.String(ns))
+	defer func() { endSpan(span, err) }()
 
 	// persist fake state
 	b, err := json.Marshal(value)
@@ -224,9 +272,9 @@ func HSet(ctx context.Context, r redis.Cmdable, ns string, name string, value in
 }
 
 // HGet gets user state from redis using the HGet command.
-func HGet(ctx context.Context, r redis.Cmdable, ns string, name string, value interface{}) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "HGet")
-	defer span.Finish()
+func HGet(ctx context.Context, r redis.Cmdable, ns string, name string, value interface{}) (err error) {
+	ctx, span := startSpan(ctx, "HGet", namespaceKey.String(ns))
+	defer func() { endSpan(span, err) }()
 
 	ok, err := r.HExists(ctx, ns, name).Result()
 	if err != nil {
@@ -246,3 +294,11 @@ func HGet(ctx context.Context, r redis.Cmdable, ns string, name string, value in
 	}
 	return nil
 }
+
+// outcome returns the lock outcome of ok.
+func outcome(ok bool, yes string, no string) string {
+	if ok {
+		return yes
+	}
+	return no
+}
@@ -9,7 +9,13 @@ import (
 
 	minisentinel "github.com/Bose/minisentinel"
 	miniredis "github.com/alicebob/miniredis/v2"
-	redis "github.com/go-redis/redis"
+	redis "github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var red *redis.Client
@@ -121,6 +127,8 @@ func TestRemove(t *testing.T) {
 }
 
 func TestExLock(t *testing.T) {
+	ctx := context.Background()
+
 	type args struct {
 		r       *redis.Client
 		key     string
@@ -137,7 +145,7 @@ func TestExLock(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ExLock(tt.args.r, tt.args.key, tt.args.uuid, tt.args.timeout)
+			got, err := ExLock(ctx, tt.args.r, tt.args.key, tt.args.uuid, tt.args.timeout)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ExLock() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -430,3 +438,67 @@ func TestMain(m *testing.M) {
 
 	os.Exit(m.Run())
 }
+
+func TestSpans(t *testing.T) {
+	ctx := context.Background()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer r.Close()
+
+	if err := Add(ctx, r, "spans:jobs", Job{Name: "user=span", Time: 1}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := Find(ctx, r, "spans:jobs", "spans:lock:", "uuid", 5); err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if _, err := Find(ctx, r, "spans:empty", "spans:lock:", "uuid", 5); err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+
+	// a redis outage is returned, not panicked
+	mr.Close()
+	if _, err := Find(ctx, r, "spans:jobs", "spans:lock:", "uuid", 5); err == nil {
+		t.Fatal("Find() error = nil, want the redis error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("ended %d spans, want 4", len(spans))
+	}
+	tests := []struct {
+		name    string
+		attrs   map[attribute.Key]string
+		errored bool
+	}{
+		{"AddJob", map[attribute.Key]string{"db.system": "redis", namespaceKey: "spans:jobs", jobNameKey: "user=span"}, false},
+		{"FindJob", map[attribute.Key]string{namespaceKey: "spans:jobs", lockNamespaceKey: "spans:lock:", jobNameKey: "user=span", lockOutcomeKey: lockAcquired}, false},
+		{"FindJob", map[attribute.Key]string{namespaceKey: "spans:empty", lockOutcomeKey: lockNoJob}, false},
+		{"FindJob", map[attribute.Key]string{namespaceKey: "spans:jobs"}, true},
+	}
+	for i, tt := range tests {
+		s := spans[i]
+		if s.Name() != tt.name || s.SpanKind() != trace.SpanKindClient {
+			t.Errorf("span %d = %s %v, want a client span %s", i, s.Name(), s.SpanKind(), tt.name)
+		}
+		attrs := map[attribute.Key]string{}
+		for _, kv := range s.Attributes() {
+			attrs[kv.Key] = kv.Value.Emit()
+		}
+		for k, v := range tt.attrs {
+			if attrs[k] != v {
+				t.Errorf("span %d %s = %q, want %q", i, k, attrs[k], v)
+			}
+		}
+		if errored := s.Status().Code == codes.Error; errored != tt.errored {
+			t.Errorf("span %d status = %v, want error %v", i, s.Status(), tt.errored)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package redis
 
 import (
 	"context"
-	"strings"
 
 	redis "github.com/go-redis/redis/v8"
 	"github.com/onmi-bv/commons/confighelper"
@@ -11,36 +10,35 @@ import (
 
 // Client defines connection configurations
 type Client struct {
-	URL                string `mapstructure:"URL"`
-	Database           int    `mapstructure:"DATABASE"`
-	Password           string `mapstructure:"PASSWORD"`
-	AuthEnabled        bool   `mapstructure:"AUTH_ENABLED"`
-	SentinelEnabled    bool   `mapstructure:"SENTINEL_ENABLED"`
-	SentinelMasterName string `mapstructure:"SENTINEL_MASTER_NAME"`
+	URL                []string `mapstructure:"URL" default:"redis:6379" required:"true"` // comma separated or JSON list of addresses
+	Database           int      `mapstructure:"DATABASE" default:"0" validate:"min=0"`
+	Password           string   `mapstructure:"PASSWORD" secret:"true"`
+	AuthEnabled        bool     `mapstructure:"AUTH_ENABLED" default:"true"`
+	SentinelEnabled    bool     `mapstructure:"SENTINEL_ENABLED" default:"false"`
+	SentinelMasterName string   `mapstructure:"SENTINEL_MASTER_NAME"`
 	redis.UniversalClient
 }
 
 // Configuration used for initialization
 type Configuration struct {
-	Path   string // Path to config file
-	Prefix string // Prefix to environment variables
+	Path    string                // Path to config file
+	Prefix  string                // Prefix to environment variables
+	Secrets confighelper.Resolver // Resolves secret:// references, i.e. for the password
+	Sources []confighelper.Option // Layered sources, i.e. confighelper.WithDotEnv, applied after Path and Prefix
 }
 
 // NewClient creates a config struct with the connection default values
 func NewClient() Client {
-	return Client{
-		URL:             "redis:6379",
-		Database:        0,
-		AuthEnabled:     true,
-		SentinelEnabled: false,
-	}
+	c := Client{}
+	confighelper.SetDefaults(&c)
+	return c
 }
 
 // Initialize creates and initializes a redis universal client.
 func (c *Client) Initialize(ctx context.Context) error {
 
 	redisOpts := &redis.UniversalOptions{
-		Addrs:      strings.Split(c.URL, ","),
+		Addrs:      c.URL,
 		DB:         c.Database,
 		MaxRetries: 5,
 		MasterName: c.SentinelMasterName,
@@ -68,24 +66,18 @@ func Init(ctx context.Context, config Configuration) (Client, error) {
 func Load(ctx context.Context, config Configuration) (c Client, err error) {
 	c = NewClient()
 
-	err = confighelper.ReadConfig(config.Path, config.Prefix, &c)
+	l := confighelper.New(confighelper.Options(config.Path, config.Prefix, config.Secrets, config.Sources...)...)
+	err = l.LoadContext(ctx, &c)
 	if err != nil {
 		return
 	}
 
-	log.Debugf("# Redis config... ")
-	log.Debugf("Redis URL: %v", c.URL)
-	log.Debugf("Redis database: %v", c.Database)
-	log.Debugf("Redis auth enabled: %v", c.AuthEnabled)
-	log.Debugf("Redis password: %v", "***")
-	log.Debugf("Redis sentinel enabled: %v", c.SentinelEnabled)
-	log.Debugf("Redis sentinel master: %v", c.SentinelMasterName)
-	log.Debugln("...")
+	log.WithFields(l.Describe(&c).Fields()).Debug("redis config")
 
 	return
 }
 
-// Healthcheck checks if the dgraph server is online using the health endpoint.
+// Healthcheck checks if the redis server is online using ping.
 func (c *Client) Healthcheck(ctx context.Context) error {
 	_, err := c.Ping(ctx).Result()
 	return err
@@ -0,0 +1,205 @@
+package tracing
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	texporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+// newExporter creates the span exporter of config.
+func newExporter(ctx context.Context, config *Config) (sdktrace.SpanExporter, error) {
+	switch config.Exporter {
+	case StackdriverExporter: // Create exporter for stackdriver
+		exporter, err := texporter.New(
+			texporter.WithContext(ctx),
+			texporter.WithProjectID(config.ProjectID),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot init stackdriver exporter")
+		}
+		return exporter, nil
+
+	case OTLPGRPCExporter:
+		exporter, err := newOTLPGRPCExporter(ctx, config)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot init otlp-grpc exporter")
+		}
+		return exporter, nil
+
+	case OTLPHTTPExporter:
+		exporter, err := newOTLPHTTPExporter(ctx, config)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot init otlp-http exporter")
+		}
+		return exporter, nil
+
+	case JaegerExporter:
+		exporter, err := newJaegerExporter(config)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot init jaeger exporter")
+		}
+		return exporter, nil
+
+	case StdoutExporter:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot init stdout exporter")
+		}
+		return exporter, nil
+	}
+	return nil, errors.New("unsupported exporter")
+}
+
+func newOTLPGRPCExporter(ctx context.Context, config *Config) (*otlptrace.Exporter, error) {
+	opts := []otlptracegrpc.Option{}
+
+	endpoint, insecure, _ := config.endpoint()
+	if endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(endpoint))
+	}
+	if insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	} else {
+		tlsConfig, err := config.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	if len(config.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(config.Headers))
+	}
+	if config.Compression == "gzip" {
+		opts = append(opts, otlptracegrpc.WithCompressor("gzip"))
+	}
+	if config.Timeout > 0 {
+		opts = append(opts, otlptracegrpc.WithTimeout(config.Timeout))
+	}
+	return otlptracegrpc.New(ctx, opts...)
+}
+
+func newOTLPHTTPExporter(ctx context.Context, config *Config) (*otlptrace.Exporter, error) {
+	opts := []otlptracehttp.Option{}
+
+	endpoint, insecure, path := config.endpoint()
+	if endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+	}
+	if path != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(path))
+	}
+	if insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	} else {
+		tlsConfig, err := config.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+	}
+	if len(config.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(config.Headers))
+	}
+	if config.Compression == "gzip" {
+		opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+	}
+	if config.Timeout > 0 {
+		opts = append(opts, otlptracehttp.WithTimeout(config.Timeout))
+	}
+	return otlptracehttp.New(ctx, opts...)
+}
+
+// newJaegerExporter creates an exporter to the jaeger collector at the endpoint URL,
+// or else to the jaeger agent of the OTEL_EXPORTER_JAEGER_AGENT_HOST and PORT env.
+func newJaegerExporter(config *Config) (*jaeger.Exporter, error) {
+	if config.Endpoint == "" {
+		return jaeger.New(jaeger.WithAgentEndpoint())
+	}
+
+	tlsConfig, err := config.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout: config.Timeout,
+		Transport: &headerTransport{
+			base:    &http.Transport{TLSClientConfig: tlsConfig},
+			headers: config.Headers,
+		},
+	}
+	return jaeger.New(jaeger.WithCollectorEndpoint(
+		jaeger.WithEndpoint(config.Endpoint),
+		jaeger.WithHTTPClient(client),
+	))
+}
+
+// headerTransport sets headers on the requests of base.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if len(t.headers) > 0 {
+		r = r.Clone(r.Context())
+		for k, v := range t.headers {
+			r.Header.Set(k, v)
+		}
+	}
+	return t.base.RoundTrip(r)
+}
+
+// endpoint returns the host:port of the endpoint and whether TLS is disabled.
+// An endpoint URL sets both, and the URL path of otlp-http.
+func (c *Config) endpoint() (host string, insecure bool, path string) {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return c.Endpoint, c.Insecure, ""
+	}
+	if u.Path != "" && u.Path != "/" {
+		path = u.Path
+	}
+	return u.Host, c.Insecure || u.Scheme == "http", path
+}
+
+// tlsConfig returns the TLS settings of the exporter.
+func (c *Config) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.TLSSkipVerify,
+	}
+
+	if c.TLSCAFile != "" {
+		pem, err := os.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("cannot parse CA file %s", c.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.TLSCertFile != "" || c.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
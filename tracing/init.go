@@ -2,16 +2,17 @@ package tracing
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
+	"time"
 
-	texporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
 	"github.com/cloudevents/sdk-go/v2/event"
 	"github.com/go-logr/logr"
 	"github.com/onmi-bv/commons/confighelper"
+	"github.com/onmi-bv/commons/internal/cetrace"
+	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 
 	"go.opentelemetry.io/otel"
+	otbridge "go.opentelemetry.io/otel/bridge/opentracing"
 	"go.opentelemetry.io/otel/propagation"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
@@ -22,14 +23,47 @@ type ExporterType string
 
 // Exporter types.
 const (
-	JeagerExporter      ExporterType = "jaeger"
 	StackdriverExporter ExporterType = "stackdriver"
+	OTLPGRPCExporter    ExporterType = "otlp-grpc"
+	OTLPHTTPExporter    ExporterType = "otlp-http"
+	JaegerExporter      ExporterType = "jaeger"
+	StdoutExporter      ExporterType = "stdout"
+
+	// Deprecated: use JaegerExporter.
+	JeagerExporter = JaegerExporter
 )
 
-// Configuration ...
-type configuration struct {
-	// Exporter type supported by commons
-	Exporter ExporterType `mapstructure:"EXPORTER"`
+// Config holds the tracing settings.
+type Config struct {
+	// Exporter type supported by commons. I.e., stackdriver, otlp-grpc, otlp-http, jaeger, stdout
+	Exporter ExporterType `mapstructure:"EXPORTER" validate:"oneof=stackdriver otlp-grpc otlp-http jaeger stdout"`
+
+	// Endpoint sets the address of the otlp and jaeger exporters: host:port or a URL for otlp,
+	// the collector URL for jaeger, i.e. http://localhost:14268/api/traces.
+	// Defaults to localhost:4317 for otlp-grpc, localhost:4318 for otlp-http and the agent for jaeger.
+	Endpoint string `mapstructure:"ENDPOINT"`
+
+	// Headers are sent with the exports of the otlp and jaeger exporters, i.e. authorization=Bearer token.
+	Headers map[string]string `mapstructure:"HEADERS" secret:"true"`
+
+	// Insecure disables TLS of the otlp exporters. An http:// endpoint disables it as well.
+	Insecure bool `mapstructure:"INSECURE" default:"false"`
+
+	// TLSCAFile sets the CA certificates verifying the endpoint, instead of the system ones.
+	TLSCAFile string `mapstructure:"TLS_CA_FILE"`
+
+	// TLSCertFile and TLSKeyFile set the client certificate.
+	TLSCertFile string `mapstructure:"TLS_CERT_FILE"`
+	TLSKeyFile  string `mapstructure:"TLS_KEY_FILE"`
+
+	// TLSSkipVerify disables the verification of the endpoint certificate.
+	TLSSkipVerify bool `mapstructure:"TLS_SKIP_VERIFY" default:"false"`
+
+	// Compression sets the compression of the otlp exports. I.e., none, gzip
+	Compression string `mapstructure:"COMPRESSION" default:"none" validate:"oneof=none gzip"`
+
+	// Timeout limits an export of the otlp and jaeger exporters.
+	Timeout time.Duration `mapstructure:"TIMEOUT" default:"10s" validate:"min=1ms"`
 
 	// ProjectID is the identifier of the Stackdriver
 	// project the user is uploading the stats data to.
@@ -53,106 +87,286 @@ type configuration struct {
 	// MaxNumberOfWorkers sets the maximum number of go rountines that send requests
 	// to Cloud Trace. The minimum number of workers is 1.
 	MaxNumberOfWorkers int `mapstructure:"MAX_NUMBER_OF_WORKERS"`
+
+	// Sampler sets the sampler. I.e., always, never, ratio, parent-ratio, rate-limited
+	Sampler SamplerType `mapstructure:"SAMPLER" default:"always" validate:"oneof=always never ratio parent-ratio rate-limited"`
+
+	// SamplerRatio sets the ratio of the traces sampled by the ratio and parent-ratio samplers.
+	SamplerRatio float64 `mapstructure:"SAMPLER_RATIO" default:"1" validate:"min=0,max=1"`
+
+	// SamplerRate sets the traces per second sampled by the rate-limited sampler.
+	// Zero samples no new traces.
+	SamplerRate float64 `mapstructure:"SAMPLER_RATE" default:"10" validate:"min=0"`
+
+	// ServiceName sets the service.name of the resource. Defaults to the name of Init.
+	ServiceName string `mapstructure:"SERVICE_NAME"`
+
+	// ServiceVersion sets the service.version of the resource.
+	ServiceVersion string `mapstructure:"SERVICE_VERSION"`
+
+	// Environment sets the deployment.environment of the resource, i.e. prod.
+	Environment string `mapstructure:"ENVIRONMENT"`
+
+	// ResourceAttributes are added to the resource, i.e. k8s.cluster.name=main.
+	ResourceAttributes map[string]string `mapstructure:"RESOURCE_ATTRIBUTES"`
+
+	// AttributeCountLimit limits the attributes per span.
+	AttributeCountLimit int `mapstructure:"ATTRIBUTE_COUNT_LIMIT" default:"128" validate:"min=1"`
+
+	// AttributeValueLengthLimit limits the length of string attribute values. Zero is unlimited.
+	AttributeValueLengthLimit int `mapstructure:"ATTRIBUTE_VALUE_LENGTH_LIMIT" default:"0" validate:"min=0"`
+
+	// EventCountLimit limits the events per span.
+	EventCountLimit int `mapstructure:"EVENT_COUNT_LIMIT" default:"128" validate:"min=1"`
+
+	// LinkCountLimit limits the links per span.
+	LinkCountLimit int `mapstructure:"LINK_COUNT_LIMIT" default:"128" validate:"min=1"`
+
+	// OpenTracingBridge sets the global OpenTracing tracer to a bridge of the opentelemetry
+	// tracer, for code still starting OpenTracing spans.
+	OpenTracingBridge bool `mapstructure:"OPENTRACING_BRIDGE" default:"false"`
 }
 
 // Tracer type
 type (
 	Tracer        = trace.Tracer
 	TraceProvider = *sdktrace.TracerProvider
-	TraceOption   func(*configuration)
+	TraceOption   func(*Config)
 )
 
 func WithLogger(l logr.Logger) TraceOption {
-	return func(c *configuration) {
+	return func(c *Config) {
 		otel.SetLogger(l)
 	}
 }
 
 func WithExporter(e ExporterType) TraceOption {
-	return func(c *configuration) {
+	return func(c *Config) {
 		c.Exporter = e
 	}
 }
 
-// Init initializes opentelemetry. The returned Tracer is ready to use.
-// The returned Exporter will be useful for flushing spans before exiting the process.
-func Init(ctx context.Context, name string, opts ...TraceOption) (Tracer, TraceProvider, error) {
+// WithSampler sets the sampler.
+func WithSampler(s SamplerType) TraceOption {
+	return func(c *Config) {
+		c.Sampler = s
+	}
+}
+
+// WithSamplerRatio sets the ratio of the traces sampled by the ratio and parent-ratio samplers.
+func WithSamplerRatio(r float64) TraceOption {
+	return func(c *Config) {
+		c.SamplerRatio = r
+	}
+}
 
-	tracer := otel.Tracer(name)
+// WithSamplerRate sets the traces per second sampled by the rate-limited sampler.
+func WithSamplerRate(perSecond float64) TraceOption {
+	return func(c *Config) {
+		c.SamplerRate = perSecond
+	}
+}
 
-	config := &configuration{}
+// WithServiceName sets the service.name of the resource.
+func WithServiceName(name string) TraceOption {
+	return func(c *Config) {
+		c.ServiceName = name
+	}
+}
 
-	// run all options
-	for _, opt := range opts {
-		opt(config)
+// WithServiceVersion sets the service.version of the resource.
+func WithServiceVersion(version string) TraceOption {
+	return func(c *Config) {
+		c.ServiceVersion = version
 	}
+}
 
-	// init config params
-	err := confighelper.ReadConfig("app.conf", "tracing", config)
-	if err != nil {
-		return tracer, nil, err
+// WithEnvironment sets the deployment.environment of the resource.
+func WithEnvironment(env string) TraceOption {
+	return func(c *Config) {
+		c.Environment = env
 	}
+}
 
-	// create exporter
-	var exporter sdktrace.SpanExporter
-
-	switch config.Exporter {
-	case StackdriverExporter: // Create exporter for stackdriver
-		exporter, err = texporter.New(
-			texporter.WithContext(ctx),
-			texporter.WithProjectID(config.ProjectID),
-		)
-		if err != nil {
-			return tracer, nil, errors.Wrap(err, "cannot init stackdriver exporter")
+// WithResourceAttributes adds attributes to the resource.
+func WithResourceAttributes(attrs map[string]string) TraceOption {
+	return func(c *Config) {
+		if c.ResourceAttributes == nil {
+			c.ResourceAttributes = map[string]string{}
 		}
+		for k, v := range attrs {
+			c.ResourceAttributes[k] = v
+		}
+	}
+}
 
-	default:
-		return tracer, nil, errors.New("unsupported exporter")
+// WithAttributeCountLimit limits the attributes per span.
+func WithAttributeCountLimit(n int) TraceOption {
+	return func(c *Config) {
+		c.AttributeCountLimit = n
 	}
+}
 
-	// Create trace provider with the exporter.
-	//
-	// By default it uses AlwaysSample() which samples all traces.
-	// In a production environment or high QPS setup please use
-	// ProbabilitySampler set at the desired probability.
-	// Example:
-	//   config := sdktrace.Config{DefaultSampler:sdktrace.ProbabilitySampler(0.0001)}
-	//   tp, err := sdktrace.NewProvider(sdktrace.WithConfig(config), ...)
-	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+// WithAttributeValueLengthLimit limits the length of string attribute values. Zero is unlimited.
+func WithAttributeValueLengthLimit(n int) TraceOption {
+	return func(c *Config) {
+		c.AttributeValueLengthLimit = n
+	}
+}
+
+// WithEventCountLimit limits the events per span.
+func WithEventCountLimit(n int) TraceOption {
+	return func(c *Config) {
+		c.EventCountLimit = n
+	}
+}
+
+// WithLinkCountLimit limits the links per span.
+func WithLinkCountLimit(n int) TraceOption {
+	return func(c *Config) {
+		c.LinkCountLimit = n
+	}
+}
+
+// WithOpenTracingBridge sets the global OpenTracing tracer to a bridge of the opentelemetry tracer.
+func WithOpenTracingBridge() TraceOption {
+	return func(c *Config) {
+		c.OpenTracingBridge = true
+	}
+}
+
+// Configuration defines how the tracing settings are loaded.
+type Configuration struct {
+	Name    string                // Name of the tracer, the default service name.
+	Path    string                // Path to config file.
+	Prefix  string                // Prefix to environment variables.
+	Secrets confighelper.Resolver // Resolves secret:// references, i.e. for the headers.
+	Sources []confighelper.Option // Layered sources, i.e. confighelper.WithDotEnv, applied after Path and Prefix.
+	Options []TraceOption         // Options applied after the file and environment.
+}
+
+// ShutdownFunc flushes the pending spans and closes the exporter.
+type ShutdownFunc func(ctx context.Context) error
+
+// Load loads the tracing settings from file and environment, then applies the options.
+func Load(ctx context.Context, conf Configuration) (Config, error) {
+	config := Config{}
+
+	l := confighelper.New(confighelper.Options(conf.Path, conf.Prefix, conf.Secrets, conf.Sources...)...)
+	if err := l.LoadContext(ctx, &config); err != nil {
+		return config, err
+	}
+
+	for _, opt := range conf.Options {
+		opt(&config)
+	}
+	return config, nil
+}
+
+// Initialize loads the tracing settings and initializes opentelemetry with the exporter,
+// sampler, resource and span limits. The returned Tracer is ready to use. The returned
+// ShutdownFunc flushes the pending spans and closes the exporter before exiting the process.
+// With the OpenTracing bridge, the spans of the returned Tracer are visible to OpenTracing.
+func Initialize(ctx context.Context, conf Configuration) (Tracer, TraceProvider, ShutdownFunc, error) {
+	tracer := otel.Tracer(conf.Name)
+
+	config, err := Load(ctx, conf)
+	if err != nil {
+		return tracer, nil, nil, err
+	}
+
+	sampler, err := newSampler(&config)
+	if err != nil {
+		return tracer, nil, nil, err
+	}
+
+	res, err := newResource(ctx, conf.Name, &config)
+	if err != nil {
+		return tracer, nil, nil, err
+	}
+
+	// create exporter
+	exporter, err := newExporter(ctx, &config)
+	if err != nil {
+		return tracer, nil, nil, err
+	}
+
+	// Create trace provider with the exporter, sampler, resource and span limits.
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(res),
+		sdktrace.WithRawSpanLimits(config.spanLimits()),
+	)
 	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagation.TraceContext{})
 
-	tracer = tp.Tracer(name)
+	// Bridge OpenTracing spans, which then share the context of the opentelemetry spans.
+	if config.OpenTracingBridge {
+		bridge, wrapper := otbridge.NewTracerPair(tp.Tracer(conf.Name))
+		bridge.SetTextMapPropagator(propagation.TraceContext{})
+		opentracing.SetGlobalTracer(bridge)
+		otel.SetTracerProvider(wrapper)
+		tracer = wrapper.Tracer(conf.Name)
+	} else {
+		tracer = tp.Tracer(conf.Name)
+	}
+
+	shutdown := func(ctx context.Context) error {
+		if err := tp.ForceFlush(ctx); err != nil {
+			tp.Shutdown(ctx)
+			return errors.Wrap(err, "cannot flush spans")
+		}
+		if err := tp.Shutdown(ctx); err != nil {
+			return errors.Wrap(err, "cannot shutdown exporter")
+		}
+		return nil
+	}
+
+	return tracer, tp, shutdown, nil
+}
+
+// Init initializes opentelemetry with the settings of app.conf and the TRACING env.
+// The returned Tracer is ready to use.
+// The returned TraceProvider will be useful for flushing spans before exiting the process.
+//
+// Deprecated: use Initialize, which honors the config path and prefix.
+func Init(ctx context.Context, name string, opts ...TraceOption) (Tracer, TraceProvider, error) {
+	tracer, tp, _, err := Initialize(ctx, Configuration{
+		Name:    name,
+		Path:    "app.conf",
+		Prefix:  "tracing",
+		Options: opts,
+	})
 	return tracer, tp, err
 }
 
-// StartSpanFromEvent extracts span context from event and starts a new remote span from the span context.
+// ContextFromEvent returns a copy of ctx with the remote span context of event, read from the
+// traceparent and tracestate extensions, or else from the legacy spancontext extension.
 func ContextFromEvent(ctx context.Context, event event.Event) context.Context {
+	return cetrace.Propagator{}.Extract(ctx, event)
+}
 
-	// parse spancontext
-	if spanContext, ok := event.Extensions()["spancontext"]; ok {
-		var sc = struct {
-			TraceID    string
-			SpanID     string
-			TraceFlags byte
-			TraceState string
-			Remote     bool
-		}{}
-
-		if scStr, ok := spanContext.(string); ok {
-			sDec, _ := base64.StdEncoding.DecodeString(scStr)
-			json.Unmarshal(sDec, &sc)
-		}
-
-		var spanContextConfig = trace.SpanContextConfig{}
-		spanContextConfig.TraceID, _ = trace.TraceIDFromHex(sc.TraceID)
-		spanContextConfig.SpanID, _ = trace.SpanIDFromHex(sc.SpanID)
-		spanContextConfig.TraceFlags = 01
-		spanContextConfig.Remote = sc.Remote
+// InjectEvent sets the span context of ctx on event as the traceparent and tracestate extensions.
+func InjectEvent(ctx context.Context, event *event.Event) {
+	cetrace.Propagator{}.Inject(ctx, event)
+}
 
-		spanContext := trace.NewSpanContext(spanContextConfig)
-		ctx = trace.ContextWithRemoteSpanContext(ctx, spanContext)
+// spanLimits returns the span limits of the config. Zero or negative limits keep the
+// defaults of opentelemetry; a zero attribute value length limit stays unlimited.
+func (c *Config) spanLimits() sdktrace.SpanLimits {
+	limits := sdktrace.NewSpanLimits()
+	if c.AttributeCountLimit > 0 {
+		limits.AttributeCountLimit = c.AttributeCountLimit
 	}
-
-	return ctx
+	if c.AttributeValueLengthLimit > 0 {
+		limits.AttributeValueLengthLimit = c.AttributeValueLengthLimit
+	}
+	if c.EventCountLimit > 0 {
+		limits.EventCountLimit = c.EventCountLimit
+	}
+	if c.LinkCountLimit > 0 {
+		limits.LinkCountLimit = c.LinkCountLimit
+	}
+	return limits
 }
@@ -0,0 +1,361 @@
+package tracing
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// receiver is an in-process OTLP trace receiver, recording the span names and headers.
+type receiver struct {
+	coltracepb.UnimplementedTraceServiceServer
+
+	mu       sync.Mutex
+	spans    []string
+	headers  map[string]string
+	resource map[string]string
+}
+
+func (r *receiver) record(req *coltracepb.ExportTraceServiceRequest, header func(string) string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rs := range req.ResourceSpans {
+		r.resource = map[string]string{}
+		for _, kv := range rs.Resource.GetAttributes() {
+			r.resource[kv.Key] = kv.Value.GetStringValue()
+		}
+		for _, ss := range rs.ScopeSpans {
+			for _, s := range ss.Spans {
+				r.spans = append(r.spans, s.Name)
+			}
+		}
+	}
+	r.headers = map[string]string{"authorization": header("authorization")}
+}
+
+func (r *receiver) received() ([]string, map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.spans...), r.headers
+}
+
+func (r *receiver) receivedResource() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.resource
+}
+
+// Export implements the OTLP gRPC trace service.
+func (r *receiver) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	r.record(req, func(k string) string {
+		if v := md.Get(k); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	})
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+// ServeHTTP implements the OTLP http trace service, with protobuf bodies.
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/v1/traces" {
+		http.NotFound(w, req)
+		return
+	}
+	body := req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = gz
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var msg coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(b, &msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.record(&msg, func(k string) string { return req.Header.Get(k) })
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	resp, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	w.Write(resp)
+}
+
+func newGRPCReceiver(t *testing.T) (*receiver, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+	r := &receiver{}
+	srv := grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(srv, r)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return r, lis.Addr().String()
+}
+
+func newHTTPReceiver(t *testing.T) (*receiver, string) {
+	r := &receiver{}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, srv.URL
+}
+
+func TestInitOTLP(t *testing.T) {
+	grpcReceiver, grpcAddr := newGRPCReceiver(t)
+	httpReceiver, httpURL := newHTTPReceiver(t)
+
+	tests := []struct {
+		name     string
+		receiver *receiver
+		env      map[string]string
+	}{
+		{
+			name:     "grpc",
+			receiver: grpcReceiver,
+			env: map[string]string{
+				"TRACING_EXPORTER": "otlp-grpc",
+				"TRACING_ENDPOINT": grpcAddr,
+				"TRACING_INSECURE": "true",
+			},
+		},
+		{
+			name:     "grpc gzip",
+			receiver: grpcReceiver,
+			env: map[string]string{
+				"TRACING_EXPORTER":    "otlp-grpc",
+				"TRACING_ENDPOINT":    "http://" + grpcAddr,
+				"TRACING_COMPRESSION": "gzip",
+				"TRACING_HEADERS":     "authorization=Bearer token",
+			},
+		},
+		{
+			name:     "http",
+			receiver: httpReceiver,
+			env: map[string]string{
+				"TRACING_EXPORTER": "otlp-http",
+				"TRACING_ENDPOINT": httpURL,
+				"TRACING_HEADERS":  "authorization=Bearer token",
+			},
+		},
+		{
+			name:     "http gzip",
+			receiver: httpReceiver,
+			env: map[string]string{
+				"TRACING_EXPORTER":    "otlp-http",
+				"TRACING_ENDPOINT":    httpURL + "/v1/traces",
+				"TRACING_COMPRESSION": "gzip",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			ctx := context.Background()
+
+			tracer, tp, err := Init(ctx, "test")
+			if err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			_, span := tracer.Start(ctx, tt.name)
+			span.End()
+			if err := tp.Shutdown(ctx); err != nil {
+				t.Fatalf("Shutdown() error = %v", err)
+			}
+
+			spans, headers := tt.receiver.received()
+			if len(spans) == 0 || spans[len(spans)-1] != tt.name {
+				t.Errorf("received spans %v, want %q", spans, tt.name)
+			}
+			if want := tt.env["TRACING_HEADERS"]; want != "" && "authorization="+headers["authorization"] != want {
+				t.Errorf("received headers %v, want %s", headers, want)
+			}
+		})
+	}
+}
+
+func TestInitJaeger(t *testing.T) {
+	var mu sync.Mutex
+	var contentType, authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		contentType, authorization = r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	t.Setenv("TRACING_EXPORTER", "jaeger")
+	t.Setenv("TRACING_ENDPOINT", srv.URL+"/api/traces")
+	t.Setenv("TRACING_HEADERS", "authorization=Bearer token")
+	ctx := context.Background()
+
+	tracer, tp, err := Init(ctx, "test")
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	_, span := tracer.Start(ctx, "jaeger")
+	span.End()
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if contentType != "application/x-thrift" || authorization != "Bearer token" {
+		t.Errorf("received %q with %q, want a thrift batch with the headers", contentType, authorization)
+	}
+}
+
+func TestInitUnsupported(t *testing.T) {
+	t.Setenv("TRACING_EXPORTER", "zipkin")
+	if _, _, err := Init(context.Background(), "test"); err == nil {
+		t.Error("Init() error = nil, want an invalid exporter")
+	}
+}
+
+func TestInitResourceAndSampler(t *testing.T) {
+	r, addr := newGRPCReceiver(t)
+
+	t.Setenv("TRACING_EXPORTER", "otlp-grpc")
+	t.Setenv("TRACING_ENDPOINT", addr)
+	t.Setenv("TRACING_INSECURE", "true")
+	t.Setenv("TRACING_SERVICE_VERSION", "1.2.3")
+	t.Setenv("TRACING_ENVIRONMENT", "prod")
+	t.Setenv("TRACING_RESOURCE_ATTRIBUTES", "team=core,service.name=ignored")
+	t.Setenv("TRACING_SAMPLER", "ratio")
+	t.Setenv("TRACING_SAMPLER_RATIO", "1")
+	ctx := context.Background()
+
+	tracer, tp, err := Init(ctx, "api", WithSampler(ParentRatioSampler))
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	// a span of a parent not sampled is dropped by the parent-ratio sampler
+	parent := trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{1},
+	}))
+	_, span := tracer.Start(parent, "dropped")
+	span.End()
+	_, span = tracer.Start(ctx, "sampled")
+	span.End()
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	spans, _ := r.received()
+	if len(spans) != 1 || spans[0] != "sampled" {
+		t.Errorf("received spans %v, want only the sampled one", spans)
+	}
+	want := map[string]string{
+		"service.name":           "api",
+		"service.version":        "1.2.3",
+		"deployment.environment": "prod",
+		"team":                   "core",
+		"telemetry.sdk.language": "go",
+	}
+	resource := r.receivedResource()
+	for k, v := range want {
+		if resource[k] != v {
+			t.Errorf("resource %s = %q, want %q", k, resource[k], v)
+		}
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	r, addr := newGRPCReceiver(t)
+
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("EXPORTER: stdout\nINSECURE: true\nSERVICE_NAME: from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OBS_ENDPOINT", addr)
+	ctx := context.Background()
+
+	tracer, _, shutdown, err := Initialize(ctx, Configuration{
+		Name:    "api",
+		Path:    path,
+		Prefix:  "obs",
+		Options: []TraceOption{WithExporter(OTLPGRPCExporter)},
+	})
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	_, span := tracer.Start(ctx, "initialized")
+	span.End()
+	if err := shutdown(ctx); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	spans, _ := r.received()
+	if len(spans) != 1 || spans[0] != "initialized" {
+		t.Errorf("received spans %v, want the span flushed on shutdown", spans)
+	}
+	if r.receivedResource()["service.name"] != "from-file" {
+		t.Errorf("service.name = %q, want the name of the file", r.receivedResource()["service.name"])
+	}
+}
+
+func TestInitOpenTracingBridge(t *testing.T) {
+	r, addr := newGRPCReceiver(t)
+
+	t.Setenv("TRACING_EXPORTER", "otlp-grpc")
+	t.Setenv("TRACING_ENDPOINT", addr)
+	t.Setenv("TRACING_INSECURE", "true")
+	ctx := context.Background()
+
+	tracer, _, shutdown, err := Initialize(ctx, Configuration{
+		Name:    "api",
+		Prefix:  "tracing",
+		Options: []TraceOption{WithOpenTracingBridge()},
+	})
+	if err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	ctx, span := tracer.Start(ctx, "parent")
+	if opentracing.SpanFromContext(ctx) == nil {
+		t.Error("SpanFromContext() = nil, want the span of the returned tracer")
+	}
+	otSpan, _ := opentracing.StartSpanFromContext(ctx, "bridged")
+	otSpan.Finish()
+	span.End()
+	if err := shutdown(ctx); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	spans, _ := r.received()
+	if len(spans) != 2 || spans[0] != "bridged" || spans[1] != "parent" {
+		t.Errorf("received spans %v, want the bridged span and its parent", spans)
+	}
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// newResource creates the resource of the spans: the OTEL_RESOURCE_ATTRIBUTES env,
+// the resource attributes, then the service name, version and environment.
+func newResource(ctx context.Context, name string, config *Config) (*resource.Resource, error) {
+	attrs := make([]attribute.KeyValue, 0, len(config.ResourceAttributes)+3)
+	for k, v := range config.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = name
+	}
+	if serviceName != "" {
+		attrs = append(attrs, semconv.ServiceNameKey.String(serviceName))
+	}
+	if config.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersionKey.String(config.ServiceVersion))
+	}
+	if config.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironmentKey.String(config.Environment))
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+		resource.WithAttributes(attrs...),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create resource")
+	}
+	return res, nil
+}
@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SamplerType defines the supported samplers.
+type SamplerType string
+
+// Sampler types.
+const (
+	// AlwaysSampler samples all spans.
+	AlwaysSampler SamplerType = "always"
+	// NeverSampler samples no spans.
+	NeverSampler SamplerType = "never"
+	// RatioSampler samples the ratio of the traces, regardless of the parent.
+	RatioSampler SamplerType = "ratio"
+	// ParentRatioSampler follows the parent span, and samples the ratio of the new traces.
+	ParentRatioSampler SamplerType = "parent-ratio"
+	// RateLimitedSampler follows the parent span, and samples at most the rate of new traces per second.
+	RateLimitedSampler SamplerType = "rate-limited"
+)
+
+// newSampler creates the sampler of config.
+func newSampler(config *Config) (sdktrace.Sampler, error) {
+	switch config.Sampler {
+	case AlwaysSampler, "":
+		return sdktrace.AlwaysSample(), nil
+	case NeverSampler:
+		return sdktrace.NeverSample(), nil
+	case RatioSampler:
+		return sdktrace.TraceIDRatioBased(config.SamplerRatio), nil
+	case ParentRatioSampler:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SamplerRatio)), nil
+	case RateLimitedSampler:
+		// the limiter would still sample a first trace at a zero rate
+		if config.SamplerRate <= 0 {
+			return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+		}
+		return sdktrace.ParentBased(newRateLimiter(config.SamplerRate, time.Now)), nil
+	}
+	return nil, fmt.Errorf("unsupported sampler %q", config.Sampler)
+}
+
+// rateLimiter samples at most rate spans per second, with bursts up to the rate.
+type rateLimiter struct {
+	rate float64
+	now  func() time.Time
+
+	mu      sync.Mutex
+	credits float64
+	last    time.Time
+}
+
+func newRateLimiter(rate float64, now func() time.Time) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		now:     now,
+		credits: math.Max(rate, 1),
+		last:    now(),
+	}
+}
+
+// ShouldSample implements sdktrace.Sampler.
+func (r *rateLimiter) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	decision := sdktrace.Drop
+	if r.take() {
+		decision = sdktrace.RecordAndSample
+	}
+	return sdktrace.SamplingResult{
+		Decision:   decision,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+// Description implements sdktrace.Sampler.
+func (r *rateLimiter) Description() string {
+	return fmt.Sprintf("RateLimited{%g}", r.rate)
+}
+
+// take reports whether a credit is available, taking it.
+func (r *rateLimiter) take() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.credits = math.Min(r.credits+now.Sub(r.last).Seconds()*r.rate, math.Max(r.rate, 1))
+	r.last = now
+
+	if r.credits < 1 {
+		return false
+	}
+	r.credits--
+	return true
+}
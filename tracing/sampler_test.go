@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := newRateLimiter(2, func() time.Time { return now })
+
+	sampled := func() int {
+		n := 0
+		for i := 0; i < 10; i++ {
+			if r.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background()}).Decision == sdktrace.RecordAndSample {
+				n++
+			}
+		}
+		return n
+	}
+
+	if n := sampled(); n != 2 {
+		t.Errorf("sampled %d spans of a burst, want 2", n)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if n := sampled(); n != 1 {
+		t.Errorf("sampled %d spans after 500ms, want 1", n)
+	}
+	now = now.Add(time.Minute)
+	if n := sampled(); n != 2 {
+		t.Errorf("sampled %d spans after a minute, want 2", n)
+	}
+}
+
+func TestNewSampler(t *testing.T) {
+	tests := []struct {
+		sampler SamplerType
+		want    string
+	}{
+		{AlwaysSampler, "AlwaysOnSampler"},
+		{NeverSampler, "AlwaysOffSampler"},
+		{RatioSampler, "TraceIDRatioBased{0.5}"},
+		{ParentRatioSampler, "ParentBased{root:TraceIDRatioBased{0.5}"},
+		{RateLimitedSampler, "ParentBased{root:RateLimited{10}"},
+	}
+	for _, tt := range tests {
+		s, err := newSampler(&Config{Sampler: tt.sampler, SamplerRatio: 0.5, SamplerRate: 10})
+		if err != nil {
+			t.Fatalf("newSampler(%s) error = %v", tt.sampler, err)
+		}
+		if d := s.Description(); len(d) < len(tt.want) || d[:len(tt.want)] != tt.want {
+			t.Errorf("newSampler(%s) = %s, want %s", tt.sampler, d, tt.want)
+		}
+	}
+
+	s, err := newSampler(&Config{Sampler: RateLimitedSampler, SamplerRate: 0})
+	if err != nil {
+		t.Fatalf("newSampler(%s) error = %v", RateLimitedSampler, err)
+	}
+	params := sdktrace.SamplingParameters{ParentContext: context.Background()}
+	if d := s.ShouldSample(params).Decision; d != sdktrace.Drop {
+		t.Errorf("rate-limited sampler at a zero rate decided %v, want %v", d, sdktrace.Drop)
+	}
+
+	if _, err := newSampler(&Config{Sampler: "unknown"}); err == nil {
+		t.Error("newSampler(unknown) error = nil, want an error")
+	}
+}
+
+func TestSpanLimits(t *testing.T) {
+	limits := (&Config{AttributeCountLimit: 10, EventCountLimit: -1}).spanLimits()
+	want := sdktrace.NewSpanLimits()
+	want.AttributeCountLimit = 10
+	if limits != want {
+		t.Errorf("spanLimits() = %+v, want %+v", limits, want)
+	}
+}